@@ -1,23 +1,19 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/google/uuid"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
-)
-
-type user struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-}
 
-type healthResponse struct {
-	Status string `json:"status"`
-	Time   string `json:"time"`
-}
+	"Practica_1/helloapi/internal/health"
+	"Practica_1/helloapi/internal/users"
+)
 
 func main() {
 	mux := http.NewServeMux()
@@ -27,25 +23,81 @@ func main() {
 		fmt.Fprintln(w, "Hello, world!")
 	})
 
-	// JSON-ответ с UUID
-	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(user{
-			ID:   uuid.NewString(), // теперь реальный UUID
-			Name: "Gopher",
-		})
-	})
+	// Пользователи: POST/GET /users, GET/PUT/DELETE /users/{id}
+	store, err := openUserStore()
+	if err != nil {
+		log.Fatalf("open user store: %v", err)
+	}
+	users.NewHandler(store).Register(mux)
 
-	// Health-check
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(healthResponse{
-			Status: "ok",
-			Time:   time.Now().Format(time.RFC3339),
-		})
-	})
+	// Health-check: /livez — процесс жив, /readyz — готов принимать трафик
+	probes := health.NewRegistry()
+	if p, ok := store.(interface{ Ping(context.Context) error }); ok {
+		probes.AddReadiness(health.Check{Name: "users_store", Fn: p.Ping, Timeout: time.Second})
+	}
+	mux.Handle("GET /livez", probes.LiveHandler())
+	mux.Handle("GET /readyz", probes.ReadyHandler())
+	mux.Handle("GET /health", probes.LiveHandler()) // старый адрес, оставлен для совместимости
+
+	srv := &http.Server{
+		Addr:              ":8080",
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("Starting on %s ...", srv.Addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	stop() // повторный сигнал — обычное завершение процесса
+
+	// Draining: сначала /readyz начинает отвечать 503, чтобы балансировщик
+	// успел убрать под из ротации, и только потом закрываем listener.
+	grace := envDuration("SHUTDOWN_GRACE", 5*time.Second)
+	log.Printf("shutting down: draining for %s", grace)
+	probes.SetDraining(true)
+	time.Sleep(grace)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), envDuration("SHUTDOWN_TIMEOUT", 15*time.Second))
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("server: %v", err)
+	}
+	log.Printf("server stopped")
+}
+
+// openUserStore: если задан USERS_FILE — JSON-файл, иначе память процесса.
+func openUserStore() (users.Store, error) {
+	if path := os.Getenv("USERS_FILE"); path != "" {
+		log.Printf("users: file store %s", path)
+		return users.OpenFileStore(path)
+	}
+	log.Printf("users: in-memory store")
+	return users.NewMemoryStore(), nil
+}
 
-	addr := ":8080"
-	log.Printf("Starting on %s ...", addr)
-	log.Fatal(http.ListenAndServe(addr, mux))
+func envDuration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Printf("%s=%q: %v, using %s", key, raw, err, def)
+		return def
+	}
+	return d
 }
@@ -0,0 +1,181 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultTimeout = 2 * time.Second
+	defaultTTL     = 5 * time.Second
+)
+
+// Check — именованная проверка зависимости.
+// Timeout ограничивает один запуск Fn, TTL — сколько держится закешированный результат.
+type Check struct {
+	Name    string
+	Fn      func(ctx context.Context) error
+	Timeout time.Duration
+	TTL     time.Duration
+}
+
+type Result struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+type report struct {
+	Status string            `json:"status"`
+	Time   string            `json:"time"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Registry хранит проверки liveness и readiness и флаг draining,
+// который на время остановки сервера переводит /readyz в 503.
+type Registry struct {
+	mu       sync.RWMutex
+	live     []*entry
+	ready    []*entry
+	draining atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// AddLiveness регистрирует проверку для /livez — только то, что лечится рестартом процесса.
+func (r *Registry) AddLiveness(c Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.live = append(r.live, newEntry(c))
+}
+
+// AddReadiness регистрирует проверку для /readyz — внешние зависимости.
+func (r *Registry) AddReadiness(c Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = append(r.ready, newEntry(c))
+}
+
+func (r *Registry) SetDraining(v bool) { r.draining.Store(v) }
+
+func (r *Registry) Draining() bool { return r.draining.Load() }
+
+// LiveHandler — GET /livez.
+func (r *Registry) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.RLock()
+		entries := r.live
+		r.mu.RUnlock()
+		r.serve(w, req, entries, false)
+	})
+}
+
+// ReadyHandler — GET /readyz; во время draining сразу отвечает 503.
+func (r *Registry) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.RLock()
+		entries := r.ready
+		r.mu.RUnlock()
+		r.serve(w, req, entries, true)
+	})
+}
+
+func (r *Registry) serve(w http.ResponseWriter, req *http.Request, entries []*entry, readiness bool) {
+	rep := report{Status: "ok", Time: time.Now().Format(time.RFC3339)}
+	code := http.StatusOK
+
+	if readiness && r.Draining() {
+		rep.Status = "draining"
+		writeJSON(w, http.StatusServiceUnavailable, rep)
+		return
+	}
+
+	if len(entries) > 0 {
+		rep.Checks = make(map[string]Result, len(entries))
+		results := make([]Result, len(entries))
+		var wg sync.WaitGroup
+		for i, e := range entries {
+			wg.Add(1)
+			go func(i int, e *entry) {
+				defer wg.Done()
+				results[i] = e.run(req.Context())
+			}(i, e)
+		}
+		wg.Wait()
+		for i, e := range entries {
+			rep.Checks[e.check.Name] = results[i]
+			if results[i].Status != "ok" {
+				rep.Status = "fail"
+				code = http.StatusServiceUnavailable
+			}
+		}
+	}
+	writeJSON(w, code, rep)
+}
+
+// entry кеширует результат проверки; mu не даёт параллельным
+// запросам запускать одну и ту же проверку одновременно.
+type entry struct {
+	check Check
+	mu    sync.Mutex
+	last  Result
+	valid bool
+}
+
+func newEntry(c Check) *entry {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.TTL <= 0 {
+		c.TTL = defaultTTL
+	}
+	return &entry{check: c}
+}
+
+func (e *entry) run(ctx context.Context) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.valid && time.Since(e.last.CheckedAt) < e.check.TTL {
+		return e.last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() { errc <- e.check.Fn(ctx) }()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := Result{Status: "ok", DurationMS: time.Since(start).Milliseconds(), CheckedAt: time.Now()}
+	if err != nil {
+		res.Status = "fail"
+		res.Error = err.Error()
+	}
+	// отмену клиентского запроса не кешируем — это не состояние зависимости
+	if !errors.Is(err, context.Canceled) {
+		e.last, e.valid = res, true
+	}
+	return res
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,132 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore держит данные в памяти и после каждой успешной мутации
+// переписывает JSON-файл целиком (через временный файл и rename,
+// чтобы при падении процесса не остаться с обрезанным файлом).
+type FileStore struct {
+	mu   sync.Mutex // сериализует мутации вместе с записью на диск
+	path string
+	mem  *MemoryStore
+}
+
+// OpenFileStore загружает пользователей из path; отсутствие файла — не ошибка.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, mem: NewMemoryStore()}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []User
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+	}
+	for _, u := range list {
+		s.mem.put(u)
+	}
+	return s, nil
+}
+
+func (s *FileStore) Create(u User) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, err := s.mem.Create(u)
+	if err != nil {
+		return User{}, err
+	}
+	if err := s.flush(); err != nil {
+		_ = s.mem.Delete(u.ID)
+		return User{}, err
+	}
+	return u, nil
+}
+
+func (s *FileStore) Get(id string) (User, error) {
+	return s.mem.Get(id)
+}
+
+func (s *FileStore) List(offset, limit int) ([]User, int, error) {
+	return s.mem.List(offset, limit)
+}
+
+func (s *FileStore) Update(id, name string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, err := s.mem.Get(id)
+	if err != nil {
+		return User{}, err
+	}
+	u, err := s.mem.Update(id, name)
+	if err != nil {
+		return User{}, err
+	}
+	if err := s.flush(); err != nil {
+		s.mem.put(old)
+		return User{}, err
+	}
+	return u, nil
+}
+
+func (s *FileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, err := s.mem.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := s.mem.Delete(id); err != nil {
+		return err
+	}
+	if err := s.flush(); err != nil {
+		s.mem.put(old)
+		return err
+	}
+	return nil
+}
+
+// Ping проверяет, что каталог с файлом доступен — для readiness-проверки.
+func (s *FileStore) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := os.Stat(filepath.Dir(s.path))
+	return err
+}
+
+// flush вызывается под s.mu.
+func (s *FileStore) flush() error {
+	data, err := json.MarshalIndent(s.mem.snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
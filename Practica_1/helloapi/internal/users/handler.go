@@ -0,0 +1,178 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type Handler struct {
+	store Store
+}
+
+func NewHandler(store Store) *Handler {
+	return &Handler{store: store}
+}
+
+// Register вешает CRUD-маршруты /users на mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /users", h.create)
+	mux.HandleFunc("GET /users", h.list)
+	mux.HandleFunc("GET /users/{id}", h.get)
+	mux.HandleFunc("PUT /users/{id}", h.update)
+	mux.HandleFunc("DELETE /users/{id}", h.delete)
+}
+
+type userReq struct {
+	Name string `json:"name"`
+}
+
+type listResp struct {
+	Items  []User `json:"items"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	name, ok := decodeName(w, r)
+	if !ok {
+		return
+	}
+	u, err := NewUser(name)
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	u, err = h.store.Create(u)
+	if err != nil {
+		writeStoreErr(w, err)
+		return
+	}
+	w.Header().Set("Location", "/users/"+u.ID)
+	writeJSON(w, http.StatusCreated, u)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultLimit)
+	if err != nil || limit <= 0 || limit > maxLimit {
+		httpError(w, http.StatusBadRequest, "limit must be in 1.."+strconv.Itoa(maxLimit))
+		return
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		httpError(w, http.StatusBadRequest, "offset must be >= 0")
+		return
+	}
+	items, total, err := h.store.List(offset, limit)
+	if err != nil {
+		writeStoreErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResp{Items: items, Total: total, Limit: limit, Offset: offset})
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	u, err := h.store.Get(id)
+	if err != nil {
+		writeStoreErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	name, ok := decodeName(w, r)
+	if !ok {
+		return
+	}
+	u, err := h.store.Update(id, name)
+	if err != nil {
+		writeStoreErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(id); err != nil {
+		writeStoreErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// helpers
+
+func decodeName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req userReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return "", false
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		httpError(w, http.StatusBadRequest, "name is required")
+		return "", false
+	}
+	return req.Name, true
+}
+
+func parseID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "invalid id")
+		return "", false
+	}
+	return id.String(), true
+}
+
+func queryInt(r *http.Request, key string, def int) (int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return def, nil
+	}
+	return strconv.Atoi(raw)
+}
+
+func writeStoreErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		httpError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrConflict):
+		httpError(w, http.StatusConflict, err.Error())
+	default:
+		httpError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func httpError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
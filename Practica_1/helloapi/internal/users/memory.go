@@ -0,0 +1,113 @@
+package users
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит пользователей в памяти процесса.
+// ids держится отсортированным, поэтому List не сортирует всю map на каждый запрос.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	items map[string]User
+	ids   []string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string]User)}
+}
+
+func (s *MemoryStore) Create(u User) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[u.ID]; ok {
+		return User{}, ErrConflict
+	}
+	s.insert(u)
+	return u, nil
+}
+
+func (s *MemoryStore) Get(id string) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.items[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return u, nil
+}
+
+func (s *MemoryStore) List(offset, limit int) ([]User, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	total := len(s.ids)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	out := make([]User, 0, end-offset)
+	for _, id := range s.ids[offset:end] {
+		out = append(out, s.items[id])
+	}
+	return out, total, nil
+}
+
+func (s *MemoryStore) Update(id, name string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.items[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	u.Name = name
+	u.UpdatedAt = time.Now().UTC()
+	s.items[id] = u
+	return u, nil
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.items, id)
+	i := sort.SearchStrings(s.ids, id)
+	s.ids = append(s.ids[:i], s.ids[i+1:]...)
+	return nil
+}
+
+// snapshot возвращает копию всех пользователей в порядке ids.
+func (s *MemoryStore) snapshot() []User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]User, 0, len(s.ids))
+	for _, id := range s.ids {
+		out = append(out, s.items[id])
+	}
+	return out
+}
+
+// put вставляет или заменяет пользователя целиком (нужен FileStore для отката).
+func (s *MemoryStore) put(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[u.ID]; ok {
+		s.items[u.ID] = u
+		return
+	}
+	s.insert(u)
+}
+
+// insert вызывается под s.mu.
+func (s *MemoryStore) insert(u User) {
+	s.items[u.ID] = u
+	i := sort.SearchStrings(s.ids, u.ID)
+	s.ids = append(s.ids, "")
+	copy(s.ids[i+1:], s.ids[i:])
+	s.ids[i] = u.ID
+}
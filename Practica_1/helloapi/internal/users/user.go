@@ -0,0 +1,41 @@
+package users
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound = errors.New("user not found")
+	ErrConflict = errors.New("user already exists")
+)
+
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store — хранилище пользователей. Реализации: MemoryStore и FileStore.
+// List возвращает пользователей в порядке создания (UUIDv7 упорядочены по времени)
+// и общее количество записей для пагинации.
+type Store interface {
+	Create(u User) (User, error)
+	Get(id string) (User, error)
+	List(offset, limit int) ([]User, int, error)
+	Update(id, name string) (User, error)
+	Delete(id string) error
+}
+
+// NewUser создаёт пользователя с time-ordered UUIDv7.
+func NewUser(name string) (User, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return User{}, err
+	}
+	now := time.Now().UTC()
+	return User{ID: id.String(), Name: name, CreatedAt: now, UpdatedAt: now}, nil
+}
@@ -1,7 +1,25 @@
 package main
 
-import "github.com/hero/practica2/internal/app"
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/hero/practica2/internal/app"
+	"github.com/hero/practica2/internal/logging"
+)
 
 func main() {
-	app.Run()
+	log, out, err := logging.New(logging.ConfigFromEnv())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "logger:", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(log)
+
+	err = app.Run(log, out)
+	out.Close()
+	if err != nil {
+		os.Exit(1)
+	}
 }
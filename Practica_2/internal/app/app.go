@@ -3,10 +3,14 @@ package app
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
 
-	"github.com/hero/practica2/utils"
+	"github.com/hero/practica2/internal/logging"
+	"github.com/hero/practica2/internal/middleware"
 )
 
 type pingResp struct {
@@ -14,19 +18,54 @@ type pingResp struct {
 	Time   string `json:"time"`
 }
 
-func Run() {
+// Run запускает серверы. out — вывод логгера: туда access log пишет строки
+// Common/Combined как есть, мимо slog.
+func Run(log *slog.Logger, out io.Writer) error {
+	format, err := middleware.ParseFormat(os.Getenv("ACCESS_LOG_FORMAT"))
+	if err != nil {
+		return err
+	}
+
+	// Порядок важен: RequestID нужен логгеру запроса, Recover стоит внутри
+	// AccessLog, чтобы запрос с паникой попал в access log со статусом 500.
+	chain := middleware.Chain(
+		middleware.RequestID,
+		logging.Middleware(log),
+		middleware.AccessLog(format, log, out),
+		middleware.Recover,
+	)
+
+	// Служебные ручки — на отдельном listener'е, по умолчанию только localhost
+	adminAddr := os.Getenv("ADMIN_ADDR")
+	if adminAddr == "" {
+		adminAddr = "127.0.0.1:8081"
+	}
+
+	addr := ":8080"
+	log.Info("server is starting", "addr", addr, "admin_addr", adminAddr, "access_log", string(format))
+	errc := make(chan error, 2)
+	go func() { errc <- http.ListenAndServe(addr, chain(routes())) }()
+	go func() { errc <- http.ListenAndServe(adminAddr, chain(adminRoutes())) }()
+	err = <-errc
+	log.Error("server error", "err", err)
+	return err
+}
+
+func routes() *http.ServeMux {
 	mux := http.NewServeMux()
+	// logging.Routed сообщает выбранный маршрут внешним middleware (access log)
+	handle := func(pattern string, h http.HandlerFunc) {
+		mux.Handle(pattern, logging.Routed(h))
+	}
 
 	// Корневой маршрут — текст
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		utils.LogRequest(r)
+	handle("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		fmt.Fprintln(w, "Hello, Go project structure!")
 	})
 
 	// JSON-маршрут /ping
-	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
-		utils.LogRequest(r)
+	handle("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		_ = json.NewEncoder(w).Encode(pingResp{
 			Status: "ok",
@@ -34,8 +73,16 @@ func Run() {
 		})
 	})
 
-	utils.LogInfo("Server is starting on :8080")
-	if err := http.ListenAndServe(":8080", mux); err != nil {
-		utils.LogError("server error: " + err.Error())
-	}
+	return mux
+}
+
+// adminRoutes — служебные ручки. Авторизации у них нет, поэтому наружу
+// (на :8080) они не публикуются.
+func adminRoutes() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Уровень логирования: GET — текущий, PUT {"level":"debug"} — сменить на лету
+	mux.Handle("/debug/loglevel", logging.Routed(logging.LevelHandler()))
+
+	return mux
 }
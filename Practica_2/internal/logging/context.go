@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+type ctxKey struct{}
+
+type requestIDKey struct{}
+
+type routeKey struct{}
+
+// WithLogger кладёт логгер в контекст.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext достаёт логгер из контекста; если его нет — slog.Default().
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// WithRequestID кладёт ID запроса в контекст. ID выбирает middleware.RequestID,
+// пакет logging его только читает.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает ID текущего запроса или "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromRequest — логгер запроса с атрибутом route (шаблон маршрута ServeMux,
+// известен только после того, как mux выбрал обработчик).
+func FromRequest(r *http.Request) *slog.Logger {
+	l := FromContext(r.Context())
+	if p := r.Pattern; p != "" {
+		l = l.With("route", p)
+	} else if p := Route(r.Context()); p != "" {
+		l = l.With("route", p)
+	}
+	return l
+}
+
+// Route — шаблон маршрута, который выбрал mux. Внешние middleware видят его
+// только после next.ServeHTTP: r.Pattern заполняется в копии запроса внутри mux,
+// поэтому маршрут передаётся наружу через общий holder в контексте.
+func Route(ctx context.Context) string {
+	if h, ok := ctx.Value(routeKey{}).(*atomic.Pointer[string]); ok {
+		if p := h.Load(); p != nil {
+			return *p
+		}
+	}
+	return ""
+}
+
+// Routed оборачивает обработчик, зарегистрированный в mux, и записывает
+// r.Pattern в holder запроса.
+func Routed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := r.Context().Value(routeKey{}).(*atomic.Pointer[string]); ok && r.Pattern != "" {
+			p := r.Pattern
+			h.Store(&p)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Middleware создаёт для каждого запроса дочерний логгер с request_id,
+// remote_addr, method и path, чтобы все строки одного запроса можно было связать.
+// Request ID берётся из контекста: ставится после middleware.RequestID.
+func Middleware(base *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := base.With(
+				slog.String("request_id", RequestID(r.Context())),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+			)
+			ctx := WithLogger(r.Context(), l)
+			ctx = context.WithValue(ctx, routeKey{}, new(atomic.Pointer[string]))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler: GET возвращает текущий уровень, PUT {"level":"debug"} меняет его.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var in levelBody
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json: " + err.Error()})
+				return
+			}
+			if err := SetLevel(in.Level); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			FromRequest(r).Warn("log level changed", "new_level", Level().String())
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, levelBody{Level: Level().String()})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
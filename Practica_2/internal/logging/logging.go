@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// level — общий для всех логгеров пакета; меняется на лету через SetLevel/LevelHandler.
+var level = new(slog.LevelVar)
+
+type Config struct {
+	Level      string // debug | info | warn | error
+	Format     string // text | json
+	File       string // путь к файлу; пусто — только stdout
+	MaxSizeMB  int    // размер файла, после которого он ротируется
+	MaxBackups int    // сколько старых файлов хранить (app.log.1 ... app.log.N)
+}
+
+// ConfigFromEnv читает LOG_LEVEL, LOG_FORMAT, LOG_FILE, LOG_MAX_SIZE_MB, LOG_MAX_BACKUPS.
+func ConfigFromEnv() Config {
+	return Config{
+		Level:      getenv("LOG_LEVEL", "info"),
+		Format:     getenv("LOG_FORMAT", "text"),
+		File:       os.Getenv("LOG_FILE"),
+		MaxSizeMB:  getenvInt("LOG_MAX_SIZE_MB", 10),
+		MaxBackups: getenvInt("LOG_MAX_BACKUPS", 3),
+	}
+}
+
+// New собирает логгер по конфигу. Второе значение — вывод логгера (stdout и,
+// если задан File, файл с ротацией): в него пишутся строки, которые не проходят
+// через slog (access log в Common/Combined). Его Close закрывает файл лога
+// (для stdout-only это no-op), его нужно вызвать при остановке приложения.
+func New(cfg Config) (*slog.Logger, io.WriteCloser, error) {
+	if err := SetLevel(cfg.Level); err != nil {
+		return nil, nil, err
+	}
+
+	out := output{Writer: os.Stdout, Closer: nopCloser{}}
+	if cfg.File != "" {
+		rf, err := OpenRotatingFile(cfg.File, int64(cfg.MaxSizeMB)<<20, cfg.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = output{Writer: io.MultiWriter(os.Stdout, rf), Closer: rf}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		h = slog.NewTextHandler(out, opts)
+	case "json":
+		h = slog.NewJSONHandler(out, opts)
+	default:
+		out.Close()
+		return nil, nil, fmt.Errorf("logging: unknown format %q", cfg.Format)
+	}
+	return slog.New(h), out, nil
+}
+
+// SetLevel меняет уровень логирования во время работы.
+func SetLevel(s string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return fmt.Errorf("logging: %w", err)
+	}
+	level.Set(l)
+	return nil
+}
+
+func Level() slog.Level { return level.Level() }
+
+type output struct {
+	io.Writer
+	io.Closer
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func getenvInt(key string, def int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile — io.WriteCloser, который при превышении maxBytes
+// переименовывает app.log -> app.log.1 -> app.log.2 ... и начинает новый файл.
+// Файлы старше maxBackups удаляются.
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+func OpenRotatingFile(path string, maxBytes int64, maxBackups int) (*RotatingFile, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("logging: max size must be positive")
+	}
+	rf := &RotatingFile{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.f == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxBytes {
+		rf.rotate()
+	}
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.f == nil {
+		return nil
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	return err
+}
+
+func (rf *RotatingFile) open() error {
+	f, size, err := openAppend(rf.path)
+	if err != nil {
+		return err
+	}
+	rf.f, rf.size = f, size
+	return nil
+}
+
+func openAppend(path string) (*os.File, int64, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, 0, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, st.Size(), nil
+}
+
+// rotate вызывается под rf.mu. Старый файл закрывается только после того,
+// как новый открыт: если переименовать или открыть не удалось, продолжаем
+// писать в старый — потерять строки лога хуже, чем превысить размер.
+func (rf *RotatingFile) rotate() {
+	if rf.maxBackups > 0 {
+		_ = os.Remove(backupName(rf.path, rf.maxBackups))
+		for i := rf.maxBackups - 1; i >= 1; i-- {
+			_ = os.Rename(backupName(rf.path, i), backupName(rf.path, i+1))
+		}
+		if err := os.Rename(rf.path, backupName(rf.path, 1)); err != nil {
+			return
+		}
+	} else if err := os.Remove(rf.path); err != nil {
+		return
+	}
+	f, size, err := openAppend(rf.path)
+	if err != nil {
+		return
+	}
+	_ = rf.f.Close()
+	rf.f, rf.size = f, size
+}
+
+func backupName(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hero/practica2/internal/logging"
+)
+
+// Format — формат строки access log.
+type Format string
+
+const (
+	FormatCommon   Format = "common"   // NCSA Common Log Format
+	FormatCombined Format = "combined" // Common + Referer и User-Agent
+	FormatJSON     Format = "json"     // одна JSON-строка на запрос
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCommon, FormatCombined, FormatJSON:
+		return f, nil
+	case "":
+		return FormatCombined, nil
+	}
+	return "", fmt.Errorf("access log: unknown format %q", s)
+}
+
+type accessEntry struct {
+	RequestID  string
+	RemoteAddr string
+	Method     string
+	URI        string
+	Route      string
+	Proto      string
+	Status     int
+	Bytes      int64
+	Duration   time.Duration
+	Referer    string
+	UserAgent  string
+}
+
+// AccessLog пишет по записи на каждый запрос после его завершения: статус и
+// размер ответа берутся из обёртки ResponseWriter, маршрут — из holder'а,
+// который заполняет mux (см. logging.Routed). JSON идёт через log, т.е. в тот
+// же handler, что и остальной лог. Common/Combined — это готовый формат строки,
+// поэтому такие строки пишутся в out как есть (обычно тот же вывод, что у log:
+// stdout и LOG_FILE с ротацией); уровень при этом учитывается так же — info.
+func AccessLog(format Format, log *slog.Logger, out io.Writer) Middleware {
+	var mu sync.Mutex // каждая строка — один Write, строки запросов не перемешиваются
+	write := func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = io.WriteString(out, line+"\n")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := Wrap(w)
+			next.ServeHTTP(rw, r)
+
+			e := accessEntry{
+				RequestID:  GetRequestID(r.Context()),
+				RemoteAddr: remoteHost(r.RemoteAddr),
+				Method:     r.Method,
+				URI:        r.RequestURI,
+				Route:      logging.Route(r.Context()),
+				Proto:      r.Proto,
+				Status:     rw.Status(),
+				Bytes:      rw.Bytes(),
+				Duration:   time.Since(start),
+				Referer:    r.Referer(),
+				UserAgent:  r.UserAgent(),
+			}
+			if format == FormatJSON {
+				logJSON(r, log, e)
+			} else if log.Enabled(r.Context(), slog.LevelInfo) {
+				write(formatLine(format, e, start))
+			}
+		})
+	}
+}
+
+// logJSON — запись "access" с полями запроса.
+func logJSON(r *http.Request, log *slog.Logger, e accessEntry) {
+	log.LogAttrs(r.Context(), slog.LevelInfo, "access",
+		slog.String("request_id", e.RequestID),
+		slog.String("remote_addr", e.RemoteAddr),
+		slog.String("method", e.Method),
+		slog.String("uri", e.URI),
+		slog.String("route", e.Route),
+		slog.String("proto", e.Proto),
+		slog.Int("status", e.Status),
+		slog.Int64("bytes", e.Bytes),
+		slog.Int64("duration_ms", e.Duration.Milliseconds()),
+		slog.String("referer", e.Referer),
+		slog.String("user_agent", e.UserAgent),
+	)
+}
+
+// formatLine — строка NCSA без перевода строки; combined добавляет Referer и User-Agent.
+func formatLine(format Format, e accessEntry, start time.Time) string {
+	if format == FormatCommon {
+		return commonLine(e, start)
+	}
+	return fmt.Sprintf("%s %q %q", commonLine(e, start), dash(e.Referer), dash(e.UserAgent))
+}
+
+// commonLine: host ident authuser [date] "request" status bytes
+func commonLine(e accessEntry, start time.Time) string {
+	size := "-"
+	if e.Bytes > 0 {
+		size = fmt.Sprint(e.Bytes)
+	}
+	return fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %s`,
+		e.RemoteAddr,
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, e.URI, e.Proto,
+		e.Status, size,
+	)
+}
+
+func remoteHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import "net/http"
+
+// Middleware — обёртка над http.Handler.
+type Middleware func(http.Handler) http.Handler
+
+// Chain собирает middleware в одну: первая в списке — самая внешняя,
+// т.е. Chain(a, b, c)(h) == a(b(c(h))).
+func Chain(mws ...Middleware) Middleware {
+	return func(h http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+		return h
+	}
+}
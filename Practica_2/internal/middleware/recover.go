@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/hero/practica2/internal/logging"
+)
+
+// Recover перехватывает панику в обработчике, пишет её со стеком в лог запроса
+// и отвечает 500, если заголовки ещё не отправлены.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := Wrap(w)
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			logging.FromRequest(r).Error("panic recovered",
+				"panic", rec,
+				"stack", string(debug.Stack()),
+			)
+			if !rw.WroteHeader() {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import "net/http"
+
+// ResponseWriter запоминает код ответа и число записанных байт.
+type ResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// Wrap оборачивает w; если w уже обёрнут, возвращает его же,
+// чтобы AccessLog и Recover видели одни и те же счётчики.
+func Wrap(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+func (rw *ResponseWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *ResponseWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(p)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Status — код ответа; 200, если обработчик ничего не записал.
+func (rw *ResponseWriter) Status() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+func (rw *ResponseWriter) Bytes() int64 { return rw.bytes }
+
+func (rw *ResponseWriter) WroteHeader() bool { return rw.status != 0 }
+
+func (rw *ResponseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap нужен http.ResponseController.
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter { return rw.ResponseWriter }
@@ -1,24 +0,0 @@
-package utils
-
-import (
-	"fmt"
-	"net/http"
-	"time"
-)
-
-func LogRequest(r *http.Request) {
-	fmt.Printf("[%s] %s %s %s\n",
-		time.Now().Format(time.RFC3339),
-		r.RemoteAddr,
-		r.Method,
-		r.URL.Path,
-	)
-}
-
-func LogInfo(msg string) {
-	fmt.Printf("[INFO] %s %s\n", time.Now().Format(time.RFC3339), msg)
-}
-
-func LogError(msg string) {
-	fmt.Printf("[ERROR] %s %s\n", time.Now().Format(time.RFC3339), msg)
-}
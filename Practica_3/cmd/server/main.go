@@ -1,16 +1,31 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"example.com/pz3-http/internal/api"
 	"example.com/pz3-http/internal/storage"
 )
 
 func main() {
+	// DATA_DIR задан — задачи переживают рестарт (журнал + снапшоты), иначе только память
 	store := storage.NewMemoryStore()
+	if dir := os.Getenv("DATA_DIR"); dir != "" {
+		var err error
+		store, err = storage.OpenMemoryStore(dir, 1000)
+		if err != nil {
+			log.Fatalf("open store: %v", err)
+		}
+		log.Printf("durable store in %s", dir)
+	}
 	h := api.NewHandlers(store)
 
 	mux := http.NewServeMux()
@@ -32,11 +47,41 @@ func main() {
 		}
 	})
 
-	// item: GET /tasks/{id}
+	// batch: POST /tasks/batch
+	mux.HandleFunc("/tasks/batch", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.BatchTasks(w, r)
+	})
+
+	// import: POST /tasks/import (text/csv или application/x-ndjson)
+	mux.HandleFunc("/tasks/import", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.ImportTasks(w, r)
+	})
+
+	// item: GET/PUT/PATCH/DELETE /tasks/{id}, POST /tasks/{id}/toggle
 	mux.HandleFunc("/tasks/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/toggle") {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			h.ToggleTask(w, r)
+			return
+		}
 		switch r.Method {
 		case http.MethodGet:
 			h.GetTask(w, r)
+		case http.MethodPut, http.MethodPatch:
+			h.UpdateTask(w, r)
+		case http.MethodDelete:
+			h.DeleteTask(w, r)
 		default:
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -44,7 +89,44 @@ func main() {
 
 	handler := api.Logging(mux)
 
-	addr := ":" + os.Getenv("PORT")
-	log.Printf("listening on %s", addr)
-	log.Fatal(http.ListenAndServe(addr, handler))
+	srv := &http.Server{
+		Addr:              ":" + os.Getenv("PORT"),
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", srv.Addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		closeStore(store)
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	stop() // повторный сигнал — обычное завершение процесса
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("server: %v", err)
+	}
+	// Запросы завершены — теперь можно сжать журнал в снапшот и закрыть его
+	closeStore(store)
+	log.Printf("server stopped")
+}
+
+func closeStore(store *storage.MemoryStore) {
+	if err := store.Close(); err != nil {
+		log.Printf("close store: %v", err)
+	}
 }
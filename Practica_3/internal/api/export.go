@@ -0,0 +1,364 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/pz3-http/internal/storage"
+)
+
+const (
+	formatJSON   = "application/json"
+	formatCSV    = "text/csv"
+	formatNDJSON = "application/x-ndjson"
+
+	exportChunk     = 500      // сколько задач выбирается из хранилища за раз при экспорте
+	importChunk     = 500      // сколько строк импорта пишется одним Batch
+	maxImportBody   = 32 << 20 // 32 MiB
+	maxImportErrors = 1000     // дальше ошибки только считаются
+)
+
+var csvHeader = []string{"id", "title", "done"}
+
+// negotiate выбирает формат ответа по заголовку Accept с учётом q-весов.
+// Без Accept или при */* — JSON, как и раньше.
+func negotiate(accept string) string {
+	best, bestQ := formatJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(raw, 64); err == nil {
+				q = v
+			}
+		}
+		var format string
+		switch mt {
+		case formatCSV:
+			format = formatCSV
+		case formatNDJSON:
+			format = formatNDJSON
+		case formatJSON, "application/*", "*/*":
+			format = formatJSON
+		default:
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// exportTasks стримит список кусками по exportChunk через курсор хранилища,
+// так что в памяти одновременно держится только один кусок.
+func (h *Handlers) exportTasks(w http.ResponseWriter, q storage.ListQuery, format string) {
+	limit := q.Limit
+	q.Limit = chunkSize(limit, 0)
+	page, err := h.Store.Query(q)
+	if err != nil {
+		BadRequest(w, err.Error())
+		return
+	}
+
+	contentType := format + "; charset=utf-8"
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	w.WriteHeader(http.StatusOK)
+
+	rows := newRowWriter(w, format)
+	rc := http.NewResponseController(w)
+	sent := 0
+	for {
+		for _, t := range page.Tasks {
+			if err := rows.write(t); err != nil {
+				return // клиент отключился
+			}
+		}
+		sent += len(page.Tasks)
+		if err := rows.flush(); err != nil {
+			return
+		}
+		_ = rc.Flush()
+
+		if page.Next == nil || (limit > 0 && sent >= limit) {
+			return
+		}
+		q.After, q.Offset, q.Limit = page.Next, 0, chunkSize(limit, sent)
+		if page, err = h.Store.Query(q); err != nil {
+			// Статус 200 уже отправлен: обрываем соединение без завершающего
+			// чанка, чтобы клиент увидел ошибку, а не принял неполный список за весь
+			log.Printf("export: query after %d rows: %v", sent, err)
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+func chunkSize(limit, sent int) int {
+	if limit > 0 && limit-sent < exportChunk {
+		return limit - sent
+	}
+	return exportChunk
+}
+
+type rowWriter interface {
+	write(t *storage.Task) error
+	flush() error
+}
+
+func newRowWriter(w io.Writer, format string) rowWriter {
+	if format == formatCSV {
+		cw := csv.NewWriter(w)
+		_ = cw.Write(csvHeader)
+		return csvRows{cw}
+	}
+	return ndjsonRows{json.NewEncoder(w)}
+}
+
+type csvRows struct{ w *csv.Writer }
+
+func (c csvRows) write(t *storage.Task) error {
+	return c.w.Write([]string{strconv.FormatInt(t.ID, 10), t.Title, strconv.FormatBool(t.Done)})
+}
+
+func (c csvRows) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonRows struct{ enc *json.Encoder }
+
+func (n ndjsonRows) write(t *storage.Task) error { return n.enc.Encode(t) }
+
+func (n ndjsonRows) flush() error { return nil }
+
+type lineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type importReport struct {
+	Imported int         `json:"imported"`
+	Failed   int         `json:"failed"`
+	Errors   []lineError `json:"errors"`
+
+	// Заполняются, если импорт остановился: хранилище не записало очередную
+	// порцию или тело не дочитано (слишком большое, битый поток). Строки до
+	// StoppedAtLine обработаны (корректные сохранены, их Imported), с неё и дальше — нет.
+	Error         string `json:"error,omitempty"`
+	StoppedAtLine int    `json:"stopped_at_line,omitempty"`
+}
+
+// readError — тело импорта не удалось дочитать (в отличие от ошибки
+// отдельной строки, дальше читать нельзя). Line — первая непрочитанная строка.
+type readError struct {
+	Line int
+	Err  error
+}
+
+func (e *readError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+
+func (e *readError) Unwrap() error { return e.Err }
+
+// importRow — строка импорта; id из экспорта игнорируется, задачи получают новые ID.
+type importRow struct {
+	line int
+	req  createTaskRequest
+	done *bool
+}
+
+// POST /tasks/import — тело в CSV (с заголовком id,title,done) или NDJSON.
+// Каждая строка проверяется по тем же правилам, что и POST /tasks; корректные
+// строки создаются, по некорректным возвращается отчёт с номерами строк.
+// Строки пишутся порциями по importChunk, каждая порция — атомарно. Если
+// хранилище не записало порцию, импорт останавливается, и в ответе 500 тот же
+// отчёт: сколько строк уже сохранено и с какой строки ничего не записано.
+// Так же (413 или 400) отвечает импорт, если тело не удалось дочитать:
+// строки, прочитанные до ошибки, сохраняются.
+func (h *Handlers) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	body := http.MaxBytesReader(w, r.Body, maxImportBody)
+
+	var read func(io.Reader, func(importRow, error) bool) error
+	switch mt {
+	case formatCSV:
+		read = readCSV
+	case formatNDJSON:
+		read = readNDJSON
+	default:
+		JSON(w, http.StatusUnsupportedMediaType, ErrorResponse{Error: "Content-Type must be text/csv or application/x-ndjson"})
+		return
+	}
+
+	rep := importReport{Errors: make([]lineError, 0)}
+	batch := make([]storage.BatchItem, 0, importChunk)
+	lines := make([]int, 0, importChunk) // номера строк элементов batch
+	var storeErr error
+
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		tasks, err := h.Store.Batch(batch)
+		if err != nil {
+			storeErr = err
+			rep.Error = err.Error()
+			rep.StoppedAtLine = lines[0]
+			return false
+		}
+		rep.Imported += len(tasks)
+		batch, lines = batch[:0], lines[:0]
+		return true
+	}
+
+	err := read(body, func(row importRow, err error) bool {
+		if err == nil {
+			err = row.req.validate()
+		}
+		if err != nil {
+			rep.Failed++
+			if len(rep.Errors) < maxImportErrors {
+				rep.Errors = append(rep.Errors, lineError{Line: row.line, Error: err.Error()})
+			}
+			return true
+		}
+		title := row.req.Title
+		batch = append(batch, storage.BatchItem{Title: &title, Done: row.done})
+		lines = append(lines, row.line)
+		if len(batch) == importChunk {
+			return flush()
+		}
+		return true
+	})
+	// и после ошибки чтения: строки до неё прочитаны целиком и уже проверены
+	if storeErr == nil {
+		flush()
+	}
+	var rerr *readError
+	if storeErr == nil && errors.As(err, &rerr) {
+		rep.Error, rep.StoppedAtLine = err.Error(), rerr.Line
+	}
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case storeErr != nil:
+		JSON(w, http.StatusInternalServerError, rep)
+	case errors.As(err, &tooLarge):
+		rep.Error = "import body too large"
+		JSON(w, http.StatusRequestEntityTooLarge, rep)
+	case err != nil:
+		JSON(w, http.StatusBadRequest, rep)
+	case rep.Imported == 0 && rep.Failed > 0:
+		JSON(w, http.StatusUnprocessableEntity, rep)
+	default:
+		JSON(w, http.StatusOK, rep)
+	}
+}
+
+func readCSV(body io.Reader, yield func(importRow, error) bool) error {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return &readError{Line: 1, Err: err}
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	titleCol, ok := col["title"]
+	if !ok {
+		return &readError{Line: 1, Err: errors.New("csv header must contain a title column")}
+	}
+	doneCol, hasDone := col["done"]
+
+	last := 1 // строка, с которой начинается последняя прочитанная запись
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var row importRow
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			row.line, last = perr.Line, perr.Line
+			if !yield(row, perr.Err) {
+				return nil
+			}
+			continue
+		}
+		if err != nil {
+			return &readError{Line: last + 1, Err: err}
+		}
+		row.line, _ = cr.FieldPos(0)
+		last = row.line
+		if titleCol >= len(rec) {
+			if !yield(row, errors.New("title is required")) {
+				return nil
+			}
+			continue
+		}
+		row.req.Title = rec[titleCol]
+		if hasDone && doneCol < len(rec) && strings.TrimSpace(rec[doneCol]) != "" {
+			done, err := strconv.ParseBool(strings.TrimSpace(rec[doneCol]))
+			if err != nil {
+				if !yield(row, errors.New("done must be true or false")) {
+					return nil
+				}
+				continue
+			}
+			row.done = &done
+		}
+		if !yield(row, nil) {
+			return nil
+		}
+	}
+}
+
+func readNDJSON(body io.Reader, yield func(importRow, error) bool) error {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		var in struct {
+			Title string `json:"title"`
+			Done  *bool  `json:"done"`
+		}
+		row := importRow{line: line}
+		if err := json.Unmarshal([]byte(raw), &in); err != nil {
+			if !yield(row, errors.New("invalid json: "+err.Error())) {
+				return nil
+			}
+			continue
+		}
+		row.req.Title, row.done = in.Title, in.Done
+		if !yield(row, nil) {
+			return nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return &readError{Line: line + 1, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/pz3-http/internal/storage"
+)
+
+// Ошибка чтения посреди тела: порции до неё уже сохранены, и клиент должен
+// узнать, сколько строк записано и с какой строки повторять.
+func TestImportStopsOnReadError(t *testing.T) {
+	const valid = importChunk + 100 // больше одной порции
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        func() string
+		wantStatus  int
+		wantStopped int
+	}{
+		{
+			name:        "ndjson_line_too_long",
+			contentType: formatNDJSON,
+			body: func() string {
+				var b strings.Builder
+				for i := 0; i < valid; i++ {
+					fmt.Fprintf(&b, "{\"title\":\"task %d\"}\n", i)
+				}
+				b.WriteString(`{"title":"` + strings.Repeat("x", 2<<20) + "\"}\n")
+				return b.String()
+			},
+			wantStatus:  http.StatusBadRequest,
+			wantStopped: valid + 1,
+		},
+		{
+			name:        "csv_body_too_large",
+			contentType: formatCSV,
+			body: func() string {
+				var b strings.Builder
+				b.WriteString("id,title,done\n")
+				for i := 0; i < valid; i++ {
+					fmt.Fprintf(&b, "%d,task %d,false\n", i, i)
+				}
+				b.WriteString("0," + strings.Repeat("x", maxImportBody) + ",false\n")
+				return b.String()
+			},
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantStopped: valid + 2, // строка 1 — заголовок
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := NewHandlers(storage.NewMemoryStore())
+			req := httptest.NewRequest(http.MethodPost, "/tasks/import", strings.NewReader(c.body()))
+			req.Header.Set("Content-Type", c.contentType)
+			rec := httptest.NewRecorder()
+			h.ImportTasks(rec, req)
+
+			if rec.Code != c.wantStatus {
+				t.Fatalf("status = %d; want %d, body %.200s", rec.Code, c.wantStatus, rec.Body.String())
+			}
+			var rep importReport
+			if err := json.Unmarshal(rec.Body.Bytes(), &rep); err != nil {
+				t.Fatalf("decode report: %v", err)
+			}
+			if rep.Imported != valid || rep.StoppedAtLine != c.wantStopped || rep.Error == "" {
+				t.Fatalf("report = imported %d, stopped_at_line %d, error %q; want %d, %d and an error",
+					rep.Imported, rep.StoppedAtLine, rep.Error, valid, c.wantStopped)
+			}
+			if n := len(h.Store.List()); n != valid {
+				t.Fatalf("store has %d tasks; want %d", n, valid)
+			}
+		})
+	}
+}
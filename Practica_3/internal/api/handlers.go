@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -17,69 +18,294 @@ func NewHandlers(store *storage.MemoryStore) *Handlers {
 	return &Handlers{Store: store}
 }
 
-// GET /tasks
+const maxLimit = 1000
+
+// GET /tasks?q=&done=true|false&sort=id|-id|title|-title&limit=&offset=|cursor=
+// Общее число подходящих задач — в X-Total-Count, курсор следующей страницы — в X-Next-Cursor.
 func (h *Handlers) ListTasks(w http.ResponseWriter, r *http.Request) {
-	tasks := h.Store.List()
-
-	q := strings.TrimSpace(r.URL.Query().Get("q"))
-	if q != "" {
-		filtered := make([]*storage.Task, 0)
-		for _, t := range tasks {
-			if strings.Contains(strings.ToLower(t.Title), strings.ToLower(q)) {
-				filtered = append(filtered, t)
-			}
-		}
-		tasks = filtered
+	q, ok := parseListQuery(w, r)
+	if !ok {
+		return
+	}
+	if format := negotiate(r.Header.Get("Accept")); format != formatJSON {
+		h.exportTasks(w, q, format)
+		return
 	}
 
-	JSON(w, http.StatusOK, tasks)
+	page, err := h.Store.Query(q)
+	if err != nil {
+		BadRequest(w, err.Error())
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.Next != nil {
+		w.Header().Set("X-Next-Cursor", page.Next.Encode())
+	}
+	JSON(w, http.StatusOK, page.Tasks)
+}
+
+func parseListQuery(w http.ResponseWriter, r *http.Request) (storage.ListQuery, bool) {
+	v := r.URL.Query()
+	q := storage.ListQuery{
+		Search: strings.TrimSpace(v.Get("q")),
+		Sort:   v.Get("sort"),
+	}
+
+	if raw := v.Get("done"); raw != "" {
+		done, err := strconv.ParseBool(raw)
+		if err != nil {
+			BadRequest(w, "done must be true or false")
+			return q, false
+		}
+		q.Done = &done
+	}
+	if raw := v.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxLimit {
+			BadRequest(w, "limit must be in 1.."+strconv.Itoa(maxLimit))
+			return q, false
+		}
+		q.Limit = n
+	}
+	if raw := v.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			BadRequest(w, "offset must be >= 0")
+			return q, false
+		}
+		q.Offset = n
+	}
+	if raw := v.Get("cursor"); raw != "" {
+		if q.Offset > 0 {
+			BadRequest(w, "use either offset or cursor, not both")
+			return q, false
+		}
+		c, err := storage.DecodeCursor(raw)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return q, false
+		}
+		q.After = c
+	}
+	return q, true
 }
 
 type createTaskRequest struct {
 	Title string `json:"title"`
 }
 
-// POST /tasks
-func (h *Handlers) CreateTask(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Content-Type") != "" &&
-		!strings.Contains(r.Header.Get("Content-Type"), "application/json") {
-		BadRequest(w, "Content-Type must be application/json")
-		return
+// validate нормализует и проверяет запрос; те же правила применяются к строкам импорта.
+func (req *createTaskRequest) validate() error {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return errors.New("title is required")
 	}
+	return nil
+}
 
+// POST /tasks
+func (h *Handlers) CreateTask(w http.ResponseWriter, r *http.Request) {
 	var req createTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		BadRequest(w, "invalid json: "+err.Error())
+	if !decodeJSON(w, r, &req) {
 		return
 	}
-	req.Title = strings.TrimSpace(req.Title)
-	if req.Title == "" {
-		BadRequest(w, "title is required")
+	if err := req.validate(); err != nil {
+		BadRequest(w, err.Error())
 		return
 	}
 
-	t := h.Store.Create(req.Title)
+	t, err := h.Store.Create(req.Title)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
 	JSON(w, http.StatusCreated, t)
 }
 
 // GET /tasks/{id}
 func (h *Handlers) GetTask(w http.ResponseWriter, r *http.Request) {
-	// ожидаем путь /tasks/123
-	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(parts) != 2 {
-		NotFound(w, "invalid path")
+	id, ok := parseID(w, r, "")
+	if !ok {
 		return
 	}
-	id, err := strconv.ParseInt(parts[1], 10, 64)
+
+	t, err := h.Store.Get(id)
 	if err != nil {
-		BadRequest(w, "invalid id")
+		NotFound(w, "task not found")
 		return
 	}
+	JSON(w, http.StatusOK, t)
+}
 
-	t, err := h.Store.Get(id)
+type updateTaskRequest struct {
+	ID    *int64  `json:"id,omitempty"`
+	Title *string `json:"title"`
+	Done  *bool   `json:"done"`
+}
+
+// PUT /tasks/{id} — нужны оба поля; PATCH /tasks/{id} — хотя бы одно.
+func (h *Handlers) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "")
+	if !ok {
+		return
+	}
+	var req updateTaskRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.ID != nil && *req.ID != id {
+		Conflict(w, "id in body does not match id in path")
+		return
+	}
+	if r.Method == http.MethodPut && (req.Title == nil || req.Done == nil) {
+		BadRequest(w, "title and done are required")
+		return
+	}
+	if req.Title == nil && req.Done == nil {
+		BadRequest(w, "nothing to update: expected title and/or done")
+		return
+	}
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			BadRequest(w, "title must not be empty")
+			return
+		}
+		req.Title = &title
+	}
+
+	t, err := h.Store.Patch(id, req.Title, req.Done)
 	if err != nil {
-		NotFound(w, "task not found")
+		storeError(w, err)
 		return
 	}
 	JSON(w, http.StatusOK, t)
 }
+
+// DELETE /tasks/{id}
+func (h *Handlers) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "")
+	if !ok {
+		return
+	}
+	if err := h.Store.Delete(id); err != nil {
+		storeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /tasks/{id}/toggle
+func (h *Handlers) ToggleTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "toggle")
+	if !ok {
+		return
+	}
+	t, err := h.Store.Toggle(id)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, t)
+}
+
+const maxBatch = 1000
+
+// POST /tasks/batch — элементы без id создаются, с id — обновляются.
+// Весь пакет применяется атомарно.
+func (h *Handlers) BatchTasks(w http.ResponseWriter, r *http.Request) {
+	var req []updateTaskRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if len(req) == 0 || len(req) > maxBatch {
+		BadRequest(w, "batch must contain 1.."+strconv.Itoa(maxBatch)+" items")
+		return
+	}
+
+	items := make([]storage.BatchItem, len(req))
+	for i, it := range req {
+		if it.Title != nil {
+			title := strings.TrimSpace(*it.Title)
+			if title == "" {
+				BadRequest(w, "item "+strconv.Itoa(i)+": title must not be empty")
+				return
+			}
+			it.Title = &title
+		}
+		if it.ID != nil {
+			if *it.ID <= 0 {
+				BadRequest(w, "item "+strconv.Itoa(i)+": invalid id")
+				return
+			}
+			items[i].ID = *it.ID
+		}
+		items[i].Title, items[i].Done = it.Title, it.Done
+	}
+
+	tasks, err := h.Store.Batch(items)
+	if err != nil {
+		var be *storage.BatchError
+		msg := err.Error()
+		if errors.As(err, &be) {
+			msg = "item " + strconv.Itoa(be.Index) + ": " + msg
+		}
+		switch {
+		case be == nil:
+			Internal(w, msg)
+		case errors.Is(err, storage.ErrNotFound):
+			NotFound(w, msg)
+		case errors.Is(err, storage.ErrConflict):
+			Conflict(w, msg+": task is referenced more than once")
+		default:
+			BadRequest(w, msg)
+		}
+		return
+	}
+	JSON(w, http.StatusOK, tasks)
+}
+
+// helpers
+
+// storeError: ErrNotFound — 404, остальное (например, ошибка записи журнала) — 500.
+func storeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		NotFound(w, "task not found")
+		return
+	}
+	Internal(w, err.Error())
+}
+
+// decodeJSON проверяет Content-Type и разбирает тело запроса в v.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.Header.Get("Content-Type") != "" &&
+		!strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		BadRequest(w, "Content-Type must be application/json")
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		BadRequest(w, "invalid json: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// parseID разбирает путь /tasks/{id} или /tasks/{id}/{suffix}.
+func parseID(w http.ResponseWriter, r *http.Request, suffix string) (int64, bool) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	want := 2
+	if suffix != "" {
+		want = 3
+	}
+	if len(parts) != want || (suffix != "" && parts[2] != suffix) {
+		NotFound(w, "invalid path")
+		return 0, false
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || id <= 0 {
+		BadRequest(w, "invalid id")
+		return 0, false
+	}
+	return id, true
+}
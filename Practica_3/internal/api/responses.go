@@ -23,6 +23,10 @@ func NotFound(w http.ResponseWriter, msg string) {
 	JSON(w, http.StatusNotFound, ErrorResponse{Error: msg})
 }
 
+func Conflict(w http.ResponseWriter, msg string) {
+	JSON(w, http.StatusConflict, ErrorResponse{Error: msg})
+}
+
 func Internal(w http.ResponseWriter, msg string) {
 	JSON(w, http.StatusInternalServerError, ErrorResponse{Error: msg})
 }
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+)
+
+// titleKey — ключ индекса по названию: регистронезависимо, при равенстве — по ID,
+// чтобы порядок был полным и детерминированным.
+type titleKey struct {
+	Title string
+	ID    int64
+}
+
+func keyOf(t *Task) titleKey {
+	return titleKey{Title: strings.ToLower(t.Title), ID: t.ID}
+}
+
+func (k titleKey) less(o titleKey) bool {
+	if k.Title != o.Title {
+		return k.Title < o.Title
+	}
+	return k.ID < o.ID
+}
+
+// index — вторичные упорядоченные индексы поверх map tasks.
+// Обновляются в apply, так что List не сортирует map на каждый запрос.
+type index struct {
+	byID    []int64
+	byTitle []titleKey
+}
+
+func (ix *index) insert(t *Task) {
+	i := sort.Search(len(ix.byID), func(i int) bool { return ix.byID[i] >= t.ID })
+	ix.byID = append(ix.byID, 0)
+	copy(ix.byID[i+1:], ix.byID[i:])
+	ix.byID[i] = t.ID
+
+	k := keyOf(t)
+	j := ix.titlePos(k)
+	ix.byTitle = append(ix.byTitle, titleKey{})
+	copy(ix.byTitle[j+1:], ix.byTitle[j:])
+	ix.byTitle[j] = k
+}
+
+func (ix *index) remove(t *Task) {
+	i := sort.Search(len(ix.byID), func(i int) bool { return ix.byID[i] >= t.ID })
+	if i < len(ix.byID) && ix.byID[i] == t.ID {
+		ix.byID = append(ix.byID[:i], ix.byID[i+1:]...)
+	}
+
+	k := keyOf(t)
+	j := ix.titlePos(k)
+	if j < len(ix.byTitle) && ix.byTitle[j] == k {
+		ix.byTitle = append(ix.byTitle[:j], ix.byTitle[j+1:]...)
+	}
+}
+
+// titlePos — позиция первого ключа, не меньшего k.
+func (ix *index) titlePos(k titleKey) int {
+	return sort.Search(len(ix.byTitle), func(i int) bool { return !ix.byTitle[i].less(k) })
+}
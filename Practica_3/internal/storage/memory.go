@@ -2,19 +2,39 @@ package storage
 
 import (
 	"errors"
+	"log"
 	"sync"
 )
 
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+)
+
 type Task struct {
 	ID    int64  `json:"id"`
 	Title string `json:"title"`
 	Done  bool   `json:"done"`
 }
 
+// BatchItem — одна операция пакетной записи: ID == 0 — создать задачу,
+// иначе обновить существующую (nil-поля не меняются).
+type BatchItem struct {
+	ID    int64
+	Title *string
+	Done  *bool
+}
+
+// MemoryStore хранит задачи в памяти. Созданный через OpenMemoryStore
+// дополнительно пишет каждое изменение в журнал на диске (см. wal.go).
 type MemoryStore struct {
 	mu    sync.RWMutex
 	auto  int64
 	tasks map[int64]*Task
+	ix    index
+
+	wal           *wal // nil — чисто in-memory режим
+	snapshotEvery int
 }
 
 func NewMemoryStore() *MemoryStore {
@@ -23,13 +43,17 @@ func NewMemoryStore() *MemoryStore {
 	}
 }
 
-func (s *MemoryStore) Create(title string) *Task {
+// Все методы возвращают копии задач, чтобы вызывающий код мог
+// сериализовать их без блокировки, пока хранилище меняется.
+
+func (s *MemoryStore) Create(title string) (*Task, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.auto++
-	t := &Task{ID: s.auto, Title: title, Done: false}
-	s.tasks[t.ID] = t
-	return t
+	t := &Task{ID: s.auto + 1, Title: title}
+	if err := s.commit(walOp{Op: "put", Task: t}); err != nil {
+		return nil, err
+	}
+	return clone(t), nil
 }
 
 func (s *MemoryStore) Get(id int64) (*Task, error) {
@@ -37,17 +61,176 @@ func (s *MemoryStore) Get(id int64) (*Task, error) {
 	defer s.mu.RUnlock()
 	t, ok := s.tasks[id]
 	if !ok {
-		return nil, errors.New("not found")
+		return nil, ErrNotFound
 	}
-	return t, nil
+	return clone(t), nil
 }
 
+// List возвращает все задачи по возрастанию ID.
 func (s *MemoryStore) List() []*Task {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	out := make([]*Task, 0, len(s.tasks))
-	for _, t := range s.tasks {
-		out = append(out, t)
+	out := make([]*Task, 0, len(s.ix.byID))
+	for _, id := range s.ix.byID {
+		out = append(out, clone(s.tasks[id]))
 	}
 	return out
 }
+
+// Update полностью заменяет title и done.
+func (s *MemoryStore) Update(id int64, title string, done bool) (*Task, error) {
+	return s.Patch(id, &title, &done)
+}
+
+// Patch меняет только переданные (не nil) поля.
+func (s *MemoryStore) Patch(id int64, title *string, done *bool) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	next := patched(t, title, done)
+	if err := s.commit(walOp{Op: "put", Task: next}); err != nil {
+		return nil, err
+	}
+	return clone(next), nil
+}
+
+// Toggle инвертирует done.
+func (s *MemoryStore) Toggle(id int64) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	done := !t.Done
+	next := patched(t, nil, &done)
+	if err := s.commit(walOp{Op: "put", Task: next}); err != nil {
+		return nil, err
+	}
+	return clone(next), nil
+}
+
+func (s *MemoryStore) Delete(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[id]; !ok {
+		return ErrNotFound
+	}
+	return s.commit(walOp{Op: "del", ID: id})
+}
+
+// Batch применяет все операции под одной блокировкой: сначала проверяет,
+// что все обновляемые задачи существуют и ни одна не упомянута дважды,
+// и только потом пишет — либо применяются все элементы, либо ни один.
+// Ошибка оборачивается в *BatchError с индексом проблемного элемента.
+func (s *MemoryStore) Batch(items []BatchItem) ([]*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[int64]bool, len(items))
+	for i, it := range items {
+		if it.ID == 0 {
+			if it.Title == nil {
+				return nil, &BatchError{Index: i, Err: errors.New("title is required for new task")}
+			}
+			continue
+		}
+		if _, ok := s.tasks[it.ID]; !ok {
+			return nil, &BatchError{Index: i, Err: ErrNotFound}
+		}
+		if seen[it.ID] {
+			return nil, &BatchError{Index: i, Err: ErrConflict}
+		}
+		seen[it.ID] = true
+	}
+
+	ops := make([]walOp, 0, len(items))
+	nextID := s.auto
+	for _, it := range items {
+		if it.ID == 0 {
+			nextID++
+			t := &Task{ID: nextID, Title: *it.Title, Done: it.Done != nil && *it.Done}
+			ops = append(ops, walOp{Op: "put", Task: t})
+			continue
+		}
+		ops = append(ops, walOp{Op: "put", Task: patched(s.tasks[it.ID], it.Title, it.Done)})
+	}
+	if err := s.commit(ops...); err != nil {
+		return nil, err
+	}
+
+	out := make([]*Task, len(ops))
+	for i, op := range ops {
+		out[i] = clone(op.Task)
+	}
+	return out, nil
+}
+
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string { return e.Err.Error() }
+
+func (e *BatchError) Unwrap() error { return e.Err }
+
+// commit вызывается под s.mu: в durable-режиме сначала пишет операции
+// в журнал и только потом применяет их в памяти.
+func (s *MemoryStore) commit(ops ...walOp) error {
+	if s.wal != nil {
+		if err := s.wal.append(ops); err != nil {
+			return err
+		}
+	}
+	for _, op := range ops {
+		s.apply(op)
+	}
+	if s.wal != nil && s.snapshotEvery > 0 && s.wal.records >= s.snapshotEvery {
+		// изменение уже в журнале — ошибка сжатия не должна его откатывать
+		if err := s.compact(); err != nil {
+			log.Printf("wal: compact: %v", err)
+		}
+	}
+	return nil
+}
+
+// apply меняет состояние в памяти; используется и при восстановлении из журнала.
+func (s *MemoryStore) apply(op walOp) {
+	switch op.Op {
+	case "put":
+		if old, ok := s.tasks[op.Task.ID]; ok {
+			s.ix.remove(old)
+		}
+		t := clone(op.Task)
+		s.tasks[t.ID] = t
+		s.ix.insert(t)
+		if t.ID > s.auto {
+			s.auto = t.ID
+		}
+	case "del":
+		if old, ok := s.tasks[op.ID]; ok {
+			s.ix.remove(old)
+			delete(s.tasks, op.ID)
+		}
+	}
+}
+
+func patched(t *Task, title *string, done *bool) *Task {
+	next := clone(t)
+	if title != nil {
+		next.Title = *title
+	}
+	if done != nil {
+		next.Done = *done
+	}
+	return next
+}
+
+func clone(t *Task) *Task {
+	cp := *t
+	return &cp
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+const (
+	SortID        = "id"
+	SortIDDesc    = "-id"
+	SortTitle     = "title"
+	SortTitleDesc = "-title"
+)
+
+var ErrBadCursor = errors.New("invalid cursor")
+
+// ListQuery — параметры выборки для Query. Limit == 0 — без ограничения.
+// After (курсор) и Offset взаимоисключающие: курсор устойчив к вставкам
+// и удалениям между запросами, offset — нет.
+type ListQuery struct {
+	Search string // подстрока в названии, без учёта регистра
+	Done   *bool
+	Sort   string // id | -id | title | -title; пусто — id
+	Limit  int
+	Offset int
+	After  *Cursor
+}
+
+// Cursor указывает на последний элемент предыдущей страницы.
+type Cursor struct {
+	Sort  string `json:"s"`
+	ID    int64  `json:"i"`
+	Title string `json:"t,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrBadCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrBadCursor
+	}
+	return &c, nil
+}
+
+// Page — результат Query: Total — число подходящих под фильтры задач
+// без учёта пагинации, Next — курсор следующей страницы (nil, если это последняя).
+type Page struct {
+	Tasks []*Task
+	Total int
+	Next  *Cursor
+}
+
+// Query обходит упорядоченный индекс в нужном направлении, фильтрует
+// и отдаёт окно страницы. Сортировки map не происходит.
+func (s *MemoryStore) Query(q ListQuery) (Page, error) {
+	if q.Sort == "" {
+		q.Sort = SortID
+	}
+	switch q.Sort {
+	case SortID, SortIDDesc, SortTitle, SortTitleDesc:
+	default:
+		return Page{}, errors.New("unknown sort: " + q.Sort)
+	}
+	if q.After != nil && q.After.Sort != q.Sort {
+		return Page{}, ErrBadCursor
+	}
+	search := strings.ToLower(q.Search)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n, at, start := s.walker(q)
+
+	page := Page{Tasks: make([]*Task, 0)}
+	skip := q.Offset
+	var last *Task
+	for i := 0; i < n; i++ {
+		t := s.tasks[at(i)]
+		if q.Done != nil && t.Done != *q.Done {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(t.Title), search) {
+			continue
+		}
+		page.Total++
+		if i < start {
+			continue // до курсора: считаем в Total, но не отдаём
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		if q.Limit > 0 && len(page.Tasks) == q.Limit {
+			if page.Next == nil {
+				page.Next = cursorFor(q.Sort, last)
+			}
+			continue
+		}
+		page.Tasks = append(page.Tasks, clone(t))
+		last = t
+	}
+	return page, nil
+}
+
+// walker возвращает длину индекса, функцию «i-й ID в порядке сортировки»
+// и позицию, с которой начинается страница (сразу после курсора).
+func (s *MemoryStore) walker(q ListQuery) (int, func(int) int64, int) {
+	n := len(s.ix.byID)
+	var at func(int) int64
+	var pos func() int // позиция первого элемента после курсора в порядке обхода
+
+	switch q.Sort {
+	case SortID:
+		at = func(i int) int64 { return s.ix.byID[i] }
+		pos = func() int {
+			return sort.Search(n, func(i int) bool { return s.ix.byID[i] > q.After.ID })
+		}
+	case SortIDDesc:
+		at = func(i int) int64 { return s.ix.byID[n-1-i] }
+		pos = func() int {
+			return n - sort.Search(n, func(i int) bool { return s.ix.byID[i] >= q.After.ID })
+		}
+	case SortTitle:
+		at = func(i int) int64 { return s.ix.byTitle[i].ID }
+		pos = func() int {
+			k := titleKey{Title: q.After.Title, ID: q.After.ID}
+			return sort.Search(n, func(i int) bool { return k.less(s.ix.byTitle[i]) })
+		}
+	case SortTitleDesc:
+		at = func(i int) int64 { return s.ix.byTitle[n-1-i].ID }
+		pos = func() int {
+			k := titleKey{Title: q.After.Title, ID: q.After.ID}
+			return n - s.ix.titlePos(k)
+		}
+	}
+
+	start := 0
+	if q.After != nil {
+		start = pos()
+	}
+	return n, at, start
+}
+
+func cursorFor(sortBy string, t *Task) *Cursor {
+	if t == nil {
+		return nil
+	}
+	c := &Cursor{Sort: sortBy, ID: t.ID}
+	if sortBy == SortTitle || sortBy == SortTitleDesc {
+		c.Title = strings.ToLower(t.Title)
+	}
+	return c
+}
@@ -0,0 +1,318 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	walFile      = "tasks.wal"
+	snapshotFile = "tasks.snapshot.json"
+
+	// заголовок записи: длина payload (uint32) + CRC32 payload (uint32)
+	walHeaderSize = 8
+	maxRecordSize = 64 << 20
+)
+
+// walOp — одно изменение состояния. Пишем итоговое состояние задачи, а не
+// «что сделали», поэтому повторное применение записи ничего не ломает.
+type walOp struct {
+	Op   string `json:"op"` // put | del
+	Task *Task  `json:"task,omitempty"`
+	ID   int64  `json:"id,omitempty"`
+}
+
+type snapshot struct {
+	Auto  int64   `json:"auto"`
+	Tasks []*Task `json:"tasks"`
+}
+
+// wal — append-only журнал. Одна запись = список операций одного вызова
+// хранилища (для Batch — весь пакет), так что пакет либо восстановится
+// целиком, либо не восстановится вовсе.
+type wal struct {
+	dir     string
+	f       *os.File
+	records int // записей с момента последнего снапшота
+}
+
+// OpenMemoryStore открывает хранилище с журналом в каталоге dir:
+// загружает снапшот, проигрывает поверх него журнал и дальше пишет
+// каждое изменение в журнал до применения в памяти. После snapshotEvery
+// записей журнал сжимается в новый снапшот (0 — только по Compact).
+func OpenMemoryStore(dir string, snapshotEvery int) (*MemoryStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := NewMemoryStore()
+	s.snapshotEvery = snapshotEvery
+
+	if err := s.loadSnapshot(filepath.Join(dir, snapshotFile)); err != nil {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, walFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.replay(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("replay wal: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return nil, err
+	}
+	s.wal = &wal{dir: dir, f: f, records: n}
+	return s, nil
+}
+
+// Compact пишет снапшот текущего состояния и обнуляет журнал.
+func (s *MemoryStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		return nil
+	}
+	return s.compact()
+}
+
+// Close сжимает журнал и закрывает файл. Для хранилища без журнала — no-op.
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		return nil
+	}
+	err := s.compact()
+	if cerr := s.wal.f.Close(); err == nil {
+		err = cerr
+	}
+	s.wal = nil
+	return err
+}
+
+// ErrCorruptWAL — битая запись в середине журнала. Отрезать её нельзя:
+// вместе с ней пропали бы все целые записи после неё.
+var ErrCorruptWAL = errors.New("wal: corrupt record")
+
+// replay применяет записи журнала по порядку. Отрезается только недописанная
+// при падении последняя запись: она обрывается на конце файла или битая,
+// но после неё в файле ничего нет (длина уже записана, а payload — ещё нет:
+// нули или мусор). Битая запись, за которой есть данные (неверная CRC, JSON,
+// размер), — ошибка со смещением, хранилище не открывается.
+func (s *MemoryStore) replay(f *os.File) (int, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	fileSize := st.Size()
+	r := bufio.NewReader(f)
+	var (
+		offset  int64
+		records int
+		hdr     [walHeaderSize]byte
+	)
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return records, truncateTail(f, offset, err)
+			}
+			return records, err
+		}
+		size := binary.LittleEndian.Uint32(hdr[0:4])
+		sum := binary.LittleEndian.Uint32(hdr[4:8])
+		if offset+walHeaderSize+int64(size) > fileSize {
+			return records, truncateTail(f, offset, io.ErrUnexpectedEOF)
+		}
+		end := offset + walHeaderSize + int64(size)
+		// bad решает судьбу битой записи: последнюю в файле отрезаем
+		bad := func(cause error) error {
+			torn := end == fileSize
+			if !torn {
+				zero, err := zeroTail(f, offset, fileSize)
+				if err != nil {
+					return err
+				}
+				torn = zero
+			}
+			if torn {
+				return truncateTail(f, offset, cause)
+			}
+			return fmt.Errorf("%w at offset %d: %v", ErrCorruptWAL, offset, cause)
+		}
+		if size > maxRecordSize {
+			return records, bad(fmt.Errorf("size %d too large", size))
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return records, err // размер уже сверен с файлом — это ошибка чтения
+		}
+		if crc32.ChecksumIEEE(payload) != sum {
+			return records, bad(errors.New("checksum mismatch"))
+		}
+		var ops []walOp
+		if err := json.Unmarshal(payload, &ops); err != nil {
+			return records, bad(err)
+		}
+		for _, op := range ops {
+			s.apply(op)
+		}
+		offset = end
+		records++
+	}
+}
+
+// truncateTail отрезает оборванную последнюю запись начиная с offset.
+func truncateTail(f *os.File, offset int64, cause error) error {
+	log.Printf("wal: torn record at offset %d (%v), truncating", offset, cause)
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// zeroTail — от offset до конца файла одни нули: файл удлинился при падении
+// (например, место под запись выделено), а сами записи на диск не попали.
+func zeroTail(f *os.File, offset, fileSize int64) (bool, error) {
+	buf := make([]byte, 32<<10)
+	for off := offset; off < fileSize; {
+		n, err := f.ReadAt(buf[:min(int64(len(buf)), fileSize-off)], off)
+		for _, b := range buf[:n] {
+			if b != 0 {
+				return false, nil
+			}
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, err
+		}
+		if n == 0 {
+			break
+		}
+		off += int64(n)
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) loadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	for _, t := range snap.Tasks {
+		s.apply(walOp{Op: "put", Task: t})
+	}
+	if snap.Auto > s.auto {
+		s.auto = snap.Auto
+	}
+	return nil
+}
+
+// append дописывает запись и делает fsync — только после этого
+// изменение применяется в памяти.
+func (w *wal) append(ops []walOp) error {
+	payload, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[walHeaderSize:], payload)
+
+	off, err := w.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := w.f.Write(buf); err != nil {
+		w.rollback(off)
+		return err
+	}
+	if err := w.f.Sync(); err != nil {
+		w.rollback(off)
+		return err
+	}
+	w.records++
+	return nil
+}
+
+// rollback убирает частично записанную запись, чтобы следующие
+// записи не оказались за битым хвостом и не потерялись при replay.
+func (w *wal) rollback(off int64) {
+	_ = w.f.Truncate(off)
+	_, _ = w.f.Seek(off, io.SeekStart)
+}
+
+// compact вызывается под s.mu. Снапшот пишется во временный файл и
+// переименовывается, и только потом журнал обрезается. Если упасть между
+// этими шагами, при старте старый журнал проиграется поверх нового
+// снапшота — записи содержат итоговое состояние, так что результат тот же.
+func (s *MemoryStore) compact() error {
+	snap := snapshot{Auto: s.auto, Tasks: make([]*Task, 0, len(s.tasks))}
+	for _, t := range s.tasks {
+		snap.Tasks = append(snap.Tasks, t)
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(s.wal.dir, snapshotFile), data); err != nil {
+		return err
+	}
+	if err := s.wal.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.wal.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	s.wal.records = 0
+	return s.wal.f.Sync()
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// fsync каталога, чтобы rename пережил потерю питания
+	d, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
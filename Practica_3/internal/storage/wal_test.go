@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openT открывает хранилище в dir без автосжатия.
+func openT(t *testing.T, dir string) *MemoryStore {
+	t.Helper()
+	s, err := OpenMemoryStore(dir, 0)
+	if err != nil {
+		t.Fatalf("OpenMemoryStore: %v", err)
+	}
+	return s
+}
+
+// crash закрывает файл журнала без сжатия — как будто процесс упал.
+func crash(t *testing.T, s *MemoryStore) {
+	t.Helper()
+	if err := s.wal.f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s.wal = nil
+}
+
+func titles(s *MemoryStore) []string {
+	var out []string
+	for _, task := range s.List() {
+		out = append(out, task.Title)
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// header — заголовок записи журнала с заданной длиной и CRC.
+func header(size, sum uint32) []byte {
+	var h [walHeaderSize]byte
+	binary.LittleEndian.PutUint32(h[0:4], size)
+	binary.LittleEndian.PutUint32(h[4:8], sum)
+	return h[:]
+}
+
+func TestReplayTruncatesTornTail(t *testing.T) {
+	cases := []struct {
+		name string
+		tail []byte
+	}{
+		{"partial_header", []byte{3, 0, 0}},
+		{"payload_cut_short", append(header(100, 0), `[{"op":"put"`...)},
+		{"payload_zero_filled", append(header(16, 12345), make([]byte, 16)...)},
+		{"payload_garbage", append(header(5, 12345), "abcde"...)},
+		{"zero_filled_tail", make([]byte, 64)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openT(t, dir)
+			for _, title := range []string{"a", "b"} {
+				if _, err := s.Create(title); err != nil {
+					t.Fatal(err)
+				}
+			}
+			crash(t, s)
+
+			path := filepath.Join(dir, walFile)
+			good, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write(c.tail); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			s = openT(t, dir)
+			if got := titles(s); !equal(got, []string{"a", "b"}) {
+				t.Fatalf("tasks after replay = %v; want [a b]", got)
+			}
+			if st, _ := os.Stat(path); st.Size() != good.Size() {
+				t.Fatalf("wal size = %d; want truncated to %d", st.Size(), good.Size())
+			}
+			// новые записи ложатся после целых, а не за битым хвостом
+			if _, err := s.Create("c"); err != nil {
+				t.Fatal(err)
+			}
+			crash(t, s)
+			s = openT(t, dir)
+			defer s.Close()
+			if got := titles(s); !equal(got, []string{"a", "b", "c"}) {
+				t.Fatalf("tasks after reopen = %v; want [a b c]", got)
+			}
+		})
+	}
+}
+
+func TestReplayCorruptMiddle(t *testing.T) {
+	dir := t.TempDir()
+	s := openT(t, dir)
+	for _, title := range []string{"first", "second"} {
+		if _, err := s.Create(title); err != nil {
+			t.Fatal(err)
+		}
+	}
+	crash(t, s)
+
+	path := filepath.Join(dir, walFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.Index(data, []byte("first"))
+	data[i] = 'F' // CRC первой записи больше не сходится, за ней — целая вторая
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = OpenMemoryStore(dir, 0)
+	if !errors.Is(err, ErrCorruptWAL) {
+		t.Fatalf("OpenMemoryStore err = %v; want ErrCorruptWAL", err)
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, data) {
+		t.Fatal("corrupt wal was modified; it must be left for manual inspection")
+	}
+}
+
+func TestSnapshotAndLogRecovery(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenMemoryStore(dir, 3) // снапшот после каждых трёх записей
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"a", "b", "c", "d"} {
+		if _, err := s.Create(title); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a, b, c — в снапшоте, d и дальше — только в журнале
+	if _, err := os.Stat(filepath.Join(dir, snapshotFile)); err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+	if _, err := s.Update(2, "B", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	crash(t, s)
+
+	s = openT(t, dir)
+	defer s.Close()
+	if got := titles(s); !equal(got, []string{"B", "c", "d"}) {
+		t.Fatalf("tasks = %v; want [B c d]", got)
+	}
+	b, err := s.Get(2)
+	if err != nil || !b.Done {
+		t.Fatalf("Get(2) = %+v, %v; want done", b, err)
+	}
+}
+
+// Счётчик ID восстанавливается и после удаления последней задачи:
+// ID удалённой задачи не выдаётся повторно.
+func TestAutoRestored(t *testing.T) {
+	cases := []struct {
+		name  string
+		close func(t *testing.T, s *MemoryStore)
+	}{
+		{"from_snapshot", func(t *testing.T, s *MemoryStore) {
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"from_wal", crash},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openT(t, dir)
+			for _, title := range []string{"a", "b", "c"} {
+				if _, err := s.Create(title); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.Delete(3); err != nil {
+				t.Fatal(err)
+			}
+			c.close(t, s)
+
+			s = openT(t, dir)
+			defer s.Close()
+			task, err := s.Create("d")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.ID != 4 {
+				t.Fatalf("new task ID = %d; want 4", task.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package task
+
+import (
+	"strconv"
+	"strings"
+)
+
+// etag — сильный ETag задачи: её версия в кавычках.
+func etag(t *Task) string {
+	return `"` + strconv.FormatInt(t.Version, 10) + `"`
+}
+
+// ifMatch превращает заголовок If-Match в условие для Repo.
+// Сравнение сильное (RFC 9110, 13.1.1): слабые W/"..." не совпадают никогда.
+// Пустой заголовок — nil (без условия), "*" — любая существующая версия.
+func ifMatch(header string) Match {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil
+	}
+	if header == "*" {
+		return func(int64) bool { return true }
+	}
+	want := make(map[int64]bool)
+	for _, tag := range splitTags(header) {
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if v, ok := tagVersion(tag); ok {
+			want[v] = true
+		}
+	}
+	return func(v int64) bool { return want[v] }
+}
+
+// noneMatch — true, если If-None-Match совпадает с текущей версией
+// (слабое сравнение, RFC 9110, 13.1.2), т.е. клиенту можно ответить 304.
+func noneMatch(header string, t *Task) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	for _, tag := range splitTags(header) {
+		if v, ok := tagVersion(strings.TrimPrefix(tag, "W/")); ok && v == t.Version {
+			return true
+		}
+	}
+	return false
+}
+
+func splitTags(header string) []string {
+	parts := strings.Split(header, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func tagVersion(tag string) (int64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	return v, err == nil
+}
@@ -0,0 +1,176 @@
+package task
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// Event — изменение задачи. Для deleted в Task — последнее состояние удалённой задачи.
+type Event struct {
+	Epoch string // эпоха брокера, см. Broker
+	ID    uint64
+	Type  EventType
+	Task  *Task
+}
+
+// LastEventID — id события в потоке SSE: "<эпоха>-<номер>".
+func (e Event) LastEventID() string {
+	return e.Epoch + "-" + strconv.FormatUint(e.ID, 10)
+}
+
+var ErrBadEventID = errors.New("invalid Last-Event-ID")
+
+// Broker раздаёт события подписчикам внутри процесса и хранит последние
+// события в кольцевом буфере, чтобы переподключившийся клиент мог
+// дочитать пропущенное по Last-Event-ID.
+//
+// Номера событий живут только в памяти и после рестарта начинаются с 1,
+// поэтому у каждого брокера своя случайная эпоха, и она входит в id события.
+// Last-Event-ID с чужой эпохой (сервер перезапускался или клиент попал на
+// другой экземпляр) — разрыв: клиенту нужно перечитать список.
+//
+// Publish никогда не блокируется: если подписчик не успевает читать и его
+// буфер заполнен, подписка закрывается — клиент переподключится и
+// дочитает события из кольца.
+type Broker struct {
+	epoch  string
+	mu     sync.Mutex
+	seq    uint64
+	ring   []Event // ring[(start+i)%len(ring)], i < n
+	start  int
+	n      int
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+type Subscription struct {
+	C       <-chan Event
+	Backlog []Event // события после Last-Event-ID, которые ещё есть в буфере
+	Gap     bool    // часть событий после Last-Event-ID уже вытеснена или они из другой эпохи — клиенту нужно перечитать список
+	c       chan Event
+	b       *Broker
+}
+
+const subscriberBuffer = 64
+
+func NewBroker(size int) *Broker {
+	if size < 1 {
+		size = 1
+	}
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return &Broker{
+		epoch: hex.EncodeToString(b[:]),
+		ring:  make([]Event, size),
+		subs:  make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish присваивает событию очередной ID и рассылает его.
+// Repo вызывает Publish под своей блокировкой, поэтому порядок ID
+// совпадает с порядком изменений.
+func (b *Broker) Publish(typ EventType, t *Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.seq++
+	e := Event{Epoch: b.epoch, ID: b.seq, Type: typ, Task: t}
+	if b.n < len(b.ring) {
+		b.ring[(b.start+b.n)%len(b.ring)] = e
+		b.n++
+	} else {
+		b.ring[b.start] = e
+		b.start = (b.start + 1) % len(b.ring)
+	}
+	for s := range b.subs {
+		select {
+		case s.c <- e:
+		default:
+			delete(b.subs, s)
+			close(s.c)
+		}
+	}
+}
+
+// Subscribe подписывает на события после lastEventID ("" — только новые).
+// Gap выставляется, если событий после lastEventID в буфере уже нет,
+// если номер из «будущего» или эпоха чужая (сервер перезапускался).
+// Id без эпохи (до её появления это было просто число) тоже считается чужим.
+func (b *Broker) Subscribe(lastEventID string) (*Subscription, error) {
+	var (
+		lastID  uint64
+		foreign bool
+	)
+	if lastEventID != "" {
+		epoch, num, ok := strings.Cut(lastEventID, "-")
+		if !ok {
+			epoch, num = "", lastEventID
+		}
+		v, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return nil, ErrBadEventID
+		}
+		lastID, foreign = v, epoch != b.epoch
+	}
+	return b.subscribe(lastID, foreign), nil
+}
+
+func (b *Broker) subscribe(lastID uint64, foreign bool) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := &Subscription{c: make(chan Event, subscriberBuffer), b: b}
+	s.C = s.c
+	if b.closed {
+		close(s.c)
+		return s
+	}
+	if foreign {
+		s.Gap = true // номера чужой эпохи с нашими не сравнимы, backlog не отдаём
+	} else if lastID > 0 {
+		oldest := b.seq - uint64(b.n) + 1 // ID самого старого события в буфере
+		s.Gap = lastID > b.seq || lastID+1 < oldest
+		for i := 0; i < b.n; i++ {
+			if e := b.ring[(b.start+i)%len(b.ring)]; e.ID > lastID {
+				s.Backlog = append(s.Backlog, e)
+			}
+		}
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Close отписывает; вызывать можно несколько раз.
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if _, ok := s.b.subs[s]; ok {
+		delete(s.b.subs, s)
+		close(s.c)
+	}
+}
+
+// Close закрывает все подписки, новые сразу получают закрытый канал.
+// Вызывается при остановке сервера, чтобы SSE-потоки завершились.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		delete(b.subs, s)
+		close(s.c)
+	}
+}
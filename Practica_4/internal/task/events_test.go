@@ -0,0 +1,161 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func eventIDs(es []Event) []int64 {
+	out := make([]int64, 0, len(es))
+	for _, e := range es {
+		out = append(out, int64(e.ID))
+	}
+	return out
+}
+
+// publishN публикует n событий; в буфере брокера на 3 останутся 3..n.
+func publishN(b *Broker, n int) {
+	for i := 1; i <= n; i++ {
+		b.Publish(EventUpdated, &Task{ID: int64(i)})
+	}
+}
+
+func TestSubscribeResume(t *testing.T) {
+	b := NewBroker(3)
+	publishN(b, 5)
+	id := func(n int) string { return b.epoch + "-" + strconv.Itoa(n) }
+
+	tests := []struct {
+		name    string
+		last    string
+		gap     bool
+		backlog []int64
+	}{
+		{"only new", "", false, nil},
+		{"up to date", id(5), false, nil},
+		{"resume", id(3), false, []int64{4, 5}},
+		{"oldest still buffered", id(2), false, []int64{3, 4, 5}},
+		{"evicted", id(1), true, []int64{3, 4, 5}},
+		{"from the future", id(9), true, nil},
+		{"foreign epoch", "deadbeef-4", true, nil},
+		{"legacy numeric id", "4", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := b.Subscribe(tt.last)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			if s.Gap != tt.gap {
+				t.Fatalf("Gap = %v; want %v", s.Gap, tt.gap)
+			}
+			if got := eventIDs(s.Backlog); !equalIDs(got, tt.backlog) {
+				t.Fatalf("Backlog = %v; want %v", got, tt.backlog)
+			}
+		})
+	}
+
+	for _, bad := range []string{"x", b.epoch + "-", b.epoch + "-x"} {
+		if _, err := b.Subscribe(bad); !errors.Is(err, ErrBadEventID) {
+			t.Errorf("Subscribe(%q) = %v; want ErrBadEventID", bad, err)
+		}
+	}
+}
+
+func TestBrokerEpoch(t *testing.T) {
+	a, b := NewBroker(8), NewBroker(8)
+	if a.epoch == b.epoch {
+		t.Fatalf("two brokers share epoch %q", a.epoch)
+	}
+	publishN(a, 2)
+	publishN(b, 2)
+	// после «рестарта» номера совпадают, но id прошлого брокера — разрыв
+	s, err := b.Subscribe(a.epoch + "-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if !s.Gap || len(s.Backlog) != 0 {
+		t.Fatalf("foreign id: Gap = %v, Backlog = %v; want reset without backlog", s.Gap, eventIDs(s.Backlog))
+	}
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	b := NewBroker(subscriberBuffer * 2)
+	s, err := b.Subscribe("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publishN(b, subscriberBuffer+1) // Publish не блокируется на заполненном буфере
+	n := 0
+	for range s.C {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Fatalf("received %d events before close; want %d", n, subscriberBuffer)
+	}
+	s.Close() // повторное закрытие безопасно
+
+	b.Close()
+	s, _ = b.Subscribe("")
+	if _, ok := <-s.C; ok {
+		t.Fatal("subscription after broker Close is open")
+	}
+}
+
+func TestEventsStream(t *testing.T) {
+	repo := NewRepo()
+	for _, title := range []string{"a", "b", "c"} {
+		if _, err := repo.Create(Fields{Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	epoch := repo.Events().epoch
+	h := NewHandler(repo).Routes()
+
+	// отменённый контекст: обработчик отдаёт начало потока и сразу выходит
+	stream := func(header, query string) *httptest.ResponseRecorder {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodGet, "/events"+query, nil).WithContext(ctx)
+		if header != "" {
+			req.Header.Set("Last-Event-ID", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := stream(epoch+"-1", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, Content-Type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, "event: reset") {
+		t.Fatalf("resume within buffer sent reset:\n%s", body)
+	}
+	for _, want := range []string{"id: " + epoch + "-2\nevent: created\ndata: {", "id: " + epoch + "-3\n"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("stream lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, epoch+"-1\n") {
+		t.Fatalf("stream repeats the acknowledged event:\n%s", body)
+	}
+
+	// id прошлого запуска (через query, как у клиентов без заголовка) — reset без backlog
+	body = stream("", "?last_event_id=0badc0de-2").Body.String()
+	if !strings.HasPrefix(body, "retry: 3000\n\nevent: reset\ndata: {}\n\n") || strings.Contains(body, "id: ") {
+		t.Fatalf("foreign epoch stream:\n%s", body)
+	}
+
+	if rec := stream("garbage", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad Last-Event-ID: status = %d; want 400", rec.Code)
+	}
+}
@@ -2,14 +2,23 @@ package task
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 type Handler struct {
 	repo *Repo
+	// RequireIfMatch — PUT и DELETE без If-Match отклоняются с 428,
+	// чтобы клиенты не могли случайно перезаписать чужие изменения.
+	RequireIfMatch bool
+	// Timeout ограничивает обычные запросы (chi middleware.Timeout);
+	// на поток /events не действует. 0 — без ограничения.
+	Timeout time.Duration
 }
 
 func NewHandler(repo *Repo) *Handler {
@@ -18,16 +27,46 @@ func NewHandler(repo *Repo) *Handler {
 
 func (h *Handler) Routes() chi.Router {
 	r := chi.NewRouter()
-	r.Get("/", h.list)
-	r.Post("/", h.create)
-	r.Get("/{id}", h.get)
-	r.Put("/{id}", h.update)
-	r.Delete("/{id}", h.delete)
+	r.Get("/events", h.events)
+	r.Group(func(r chi.Router) {
+		if h.Timeout > 0 {
+			r.Use(middleware.Timeout(h.Timeout))
+		}
+		r.Get("/", h.list)
+		r.Post("/", h.create)
+		r.Get("/{id}", h.get)
+		r.Get("/{id}/children", h.children)
+		r.Put("/{id}", h.update)
+		r.Delete("/{id}", h.delete)
+	})
 	return r
 }
 
 func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, h.repo.List())
+	q, err := ParseQuery(r.URL.Query(), time.Now())
+	if err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tasks := h.repo.Find(q)
+	if r.URL.Query().Get("tree") == "true" {
+		writeJSON(w, http.StatusOK, BuildTree(tasks))
+		return
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (h *Handler) children(w http.ResponseWriter, r *http.Request) {
+	id, bad := parseID(w, r)
+	if bad {
+		return
+	}
+	kids, err := h.repo.Children(id)
+	if err != nil {
+		repoError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, kids)
 }
 
 func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
@@ -40,26 +79,58 @@ func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
 		httpError(w, http.StatusNotFound, err.Error())
 		return
 	}
+	w.Header().Set("ETag", etag(t))
+	if noneMatch(r.Header.Get("If-None-Match"), t) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	writeJSON(w, http.StatusOK, t)
 }
 
 type createReq struct {
-	Title string `json:"title"`
+	Title        string     `json:"title"`
+	ParentID     int64      `json:"parent_id"`
+	DueAt        *time.Time `json:"due_at"`
+	Priority     Priority   `json:"priority"`
+	Labels       []string   `json:"labels"`
+	Recurrence   *Rule      `json:"recurrence"`
+	RemindBefore Duration   `json:"remind_before"`
 }
 
 func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 	var req createReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Title == "" {
-		httpError(w, http.StatusBadRequest, "invalid json: require non-empty title")
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return
+	}
+	t, err := h.repo.Create(Fields{
+		Title:        req.Title,
+		ParentID:     req.ParentID,
+		DueAt:        req.DueAt,
+		Priority:     req.Priority,
+		Labels:       req.Labels,
+		Recurrence:   req.Recurrence,
+		RemindBefore: req.RemindBefore,
+	})
+	if err != nil {
+		repoError(w, err)
 		return
 	}
-	t := h.repo.Create(req.Title)
+	w.Header().Set("ETag", etag(t))
 	writeJSON(w, http.StatusCreated, t)
 }
 
+// updateReq — PUT заменяет задачу целиком: не переданные parent_id, due_at,
+// priority, labels, recurrence и remind_before сбрасываются.
 type updateReq struct {
-	Title string `json:"title"`
-	Done  bool   `json:"done"`
+	Title        string     `json:"title"`
+	ParentID     int64      `json:"parent_id"`
+	Done         bool       `json:"done"`
+	DueAt        *time.Time `json:"due_at"`
+	Priority     Priority   `json:"priority"`
+	Labels       []string   `json:"labels"`
+	Recurrence   *Rule      `json:"recurrence"`
+	RemindBefore Duration   `json:"remind_before"`
 }
 
 func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
@@ -67,16 +138,31 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 	if bad {
 		return
 	}
+	match, ok := h.precondition(w, r)
+	if !ok {
+		return
+	}
 	var req updateReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Title == "" {
-		httpError(w, http.StatusBadRequest, "invalid json: require non-empty title")
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, http.StatusBadRequest, "invalid json: "+err.Error())
 		return
 	}
-	t, err := h.repo.Update(id, req.Title, req.Done)
+	f := Fields{
+		Title:        req.Title,
+		ParentID:     req.ParentID,
+		Done:         req.Done,
+		DueAt:        req.DueAt,
+		Priority:     req.Priority,
+		Labels:       req.Labels,
+		Recurrence:   req.Recurrence,
+		RemindBefore: req.RemindBefore,
+	}
+	t, err := h.repo.Update(id, f, match)
 	if err != nil {
-		httpError(w, http.StatusNotFound, err.Error())
+		repoError(w, err)
 		return
 	}
+	w.Header().Set("ETag", etag(t))
 	writeJSON(w, http.StatusOK, t)
 }
 
@@ -85,8 +171,21 @@ func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
 	if bad {
 		return
 	}
-	if err := h.repo.Delete(id); err != nil {
-		httpError(w, http.StatusNotFound, err.Error())
+	var cascade bool
+	if raw := r.URL.Query().Get("cascade"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			httpError(w, http.StatusBadRequest, "cascade must be true or false")
+			return
+		}
+		cascade = v
+	}
+	match, ok := h.precondition(w, r)
+	if !ok {
+		return
+	}
+	if err := h.repo.Delete(id, match, cascade); err != nil {
+		repoError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -94,6 +193,33 @@ func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
 
 // helpers
 
+// precondition разбирает If-Match; в режиме RequireIfMatch без заголовка отвечает 428.
+func (h *Handler) precondition(w http.ResponseWriter, r *http.Request) (Match, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" && h.RequireIfMatch {
+		httpError(w, http.StatusPreconditionRequired, "If-Match header is required")
+		return nil, false
+	}
+	return ifMatch(header), true
+}
+
+func repoError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		httpError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalid):
+		httpError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrPreconditionFailed):
+		httpError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, ErrHasChildren):
+		httpError(w, http.StatusConflict, err.Error()+": delete them first or use cascade=true")
+	case errors.Is(err, ErrCycle):
+		httpError(w, http.StatusConflict, err.Error())
+	default:
+		httpError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
 func parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
 	raw := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(raw, 10, 64)
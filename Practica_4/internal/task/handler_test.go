@@ -0,0 +1,125 @@
+package task
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// do выполняет запрос к роутеру задач; headers — пары имя, значение.
+func do(t *testing.T, h http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGetIfNoneMatch(t *testing.T) {
+	repo := NewRepo()
+	if _, err := repo.Create(Fields{Title: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(repo).Routes()
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no header", "", http.StatusOK},
+		{"current", `"1"`, http.StatusNotModified},
+		{"weak current", `W/"1"`, http.StatusNotModified},
+		{"in list", `"7", "1"`, http.StatusNotModified},
+		{"star", "*", http.StatusNotModified},
+		{"stale", `"2"`, http.StatusOK},
+		{"unquoted", "1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(t, h, http.MethodGet, "/1", "", "If-None-Match", tt.header)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d; want %d", rec.Code, tt.want)
+			}
+			if got := rec.Header().Get("ETag"); got != `"1"` {
+				t.Fatalf("ETag = %q; want %q", got, `"1"`)
+			}
+			if tt.want == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Fatalf("304 with body %q", rec.Body)
+			}
+		})
+	}
+}
+
+func TestUpdateIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		require  bool
+		header   string
+		want     int
+		wantETag string
+	}{
+		{"no header", false, "", http.StatusOK, `"2"`},
+		{"current", false, `"1"`, http.StatusOK, `"2"`},
+		{"in list", false, `"5", "1"`, http.StatusOK, `"2"`},
+		{"star", false, "*", http.StatusOK, `"2"`},
+		{"stale", false, `"0"`, http.StatusPreconditionFailed, ""},
+		{"weak never matches", false, `W/"1"`, http.StatusPreconditionFailed, ""},
+		{"required missing", true, "", http.StatusPreconditionRequired, ""},
+		{"required current", true, `"1"`, http.StatusOK, `"2"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewRepo()
+			if _, err := repo.Create(Fields{Title: "a"}); err != nil {
+				t.Fatal(err)
+			}
+			h := NewHandler(repo)
+			h.RequireIfMatch = tt.require
+			rec := do(t, h.Routes(), http.MethodPut, "/1", `{"title":"b"}`, "If-Match", tt.header)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d; want %d (%s)", rec.Code, tt.want, rec.Body)
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Fatalf("ETag = %q; want %q", got, tt.wantETag)
+			}
+			// отклонённое обновление задачу не меняет
+			cur, _ := repo.Get(1)
+			wantTitle, wantVersion := "b", int64(2)
+			if tt.want != http.StatusOK {
+				wantTitle, wantVersion = "a", 1
+			}
+			if cur.Title != wantTitle || cur.Version != wantVersion {
+				t.Fatalf("task = %q v%d; want %q v%d", cur.Title, cur.Version, wantTitle, wantVersion)
+			}
+		})
+	}
+}
+
+func TestDeleteIfMatch(t *testing.T) {
+	repo := NewRepo()
+	if _, err := repo.Create(Fields{Title: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(repo)
+	h.RequireIfMatch = true
+	routes := h.Routes()
+
+	if rec := do(t, routes, http.MethodDelete, "/1", ""); rec.Code != http.StatusPreconditionRequired {
+		t.Fatalf("without If-Match: status = %d; want 428", rec.Code)
+	}
+	if rec := do(t, routes, http.MethodDelete, "/1", "", "If-Match", `"2"`); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: status = %d; want 412", rec.Code)
+	}
+	if rec := do(t, routes, http.MethodDelete, "/1", "", "If-Match", `"1"`); rec.Code != http.StatusNoContent {
+		t.Fatalf("current If-Match: status = %d; want 204", rec.Code)
+	}
+	// для удалённой задачи даже "*" не совпадает: 404, а не 412
+	if rec := do(t, routes, http.MethodDelete, "/1", "", "If-Match", "*"); rec.Code != http.StatusNotFound {
+		t.Fatalf("deleted task: status = %d; want 404", rec.Code)
+	}
+}
@@ -0,0 +1,178 @@
+package task
+
+import (
+	"sort"
+	"time"
+)
+
+type idSet map[int64]struct{}
+
+type dueKey struct {
+	at time.Time
+	id int64
+}
+
+// index — вторичные индексы Repo. Обновляются под r.mu вместе с items,
+// чтобы запросы по меткам, приоритету и сроку не перебирали все задачи.
+type index struct {
+	byLabel    map[string]idSet
+	byPriority map[Priority]idSet
+	byDue      []dueKey // только задачи со сроком, по возрастанию (at, id)
+	byRemind   []dueKey // невыполненные задачи с напоминанием: at = due_at − remind_before
+	byParent   map[int64]idSet
+}
+
+func newIndex() index {
+	return index{
+		byLabel:    make(map[string]idSet),
+		byPriority: make(map[Priority]idSet),
+		byParent:   make(map[int64]idSet),
+	}
+}
+
+func (ix *index) add(t *Task) {
+	for _, l := range t.Labels {
+		set := ix.byLabel[l]
+		if set == nil {
+			set = make(idSet)
+			ix.byLabel[l] = set
+		}
+		set[t.ID] = struct{}{}
+	}
+	set := ix.byPriority[t.Priority]
+	if set == nil {
+		set = make(idSet)
+		ix.byPriority[t.Priority] = set
+	}
+	set[t.ID] = struct{}{}
+
+	if t.ParentID != 0 {
+		kids := ix.byParent[t.ParentID]
+		if kids == nil {
+			kids = make(idSet)
+			ix.byParent[t.ParentID] = kids
+		}
+		kids[t.ID] = struct{}{}
+	}
+
+	if t.DueAt != nil {
+		ix.byDue = insertKey(ix.byDue, dueKey{at: *t.DueAt, id: t.ID})
+	}
+	if k, ok := remindKey(t); ok {
+		ix.byRemind = insertKey(ix.byRemind, k)
+	}
+}
+
+func (ix *index) remove(t *Task) {
+	for _, l := range t.Labels {
+		if set := ix.byLabel[l]; set != nil {
+			delete(set, t.ID)
+			if len(set) == 0 {
+				delete(ix.byLabel, l)
+			}
+		}
+	}
+	delete(ix.byPriority[t.Priority], t.ID)
+	if kids := ix.byParent[t.ParentID]; kids != nil {
+		delete(kids, t.ID)
+		if len(kids) == 0 {
+			delete(ix.byParent, t.ParentID)
+		}
+	}
+
+	if t.DueAt != nil {
+		ix.byDue = removeKey(ix.byDue, dueKey{at: *t.DueAt, id: t.ID})
+	}
+	if k, ok := remindKey(t); ok {
+		ix.byRemind = removeKey(ix.byRemind, k)
+	}
+}
+
+// remindKey — ключ byRemind; ok=false, если напоминать не о чем.
+func remindKey(t *Task) (dueKey, bool) {
+	if t.Done || t.DueAt == nil || t.RemindBefore <= 0 {
+		return dueKey{}, false
+	}
+	return dueKey{at: t.DueAt.Add(-time.Duration(t.RemindBefore)), id: t.ID}, true
+}
+
+// keyPos — позиция первого ключа в keys, не меньшего k.
+func keyPos(keys []dueKey, k dueKey) int {
+	return sort.Search(len(keys), func(i int) bool {
+		d := keys[i]
+		return d.at.After(k.at) || (d.at.Equal(k.at) && d.id >= k.id)
+	})
+}
+
+func insertKey(keys []dueKey, k dueKey) []dueKey {
+	i := keyPos(keys, k)
+	keys = append(keys, dueKey{})
+	copy(keys[i+1:], keys[i:])
+	keys[i] = k
+	return keys
+}
+
+func removeKey(keys []dueKey, k dueKey) []dueKey {
+	i := keyPos(keys, k)
+	if i < len(keys) && keys[i] == k {
+		keys = append(keys[:i], keys[i+1:]...)
+	}
+	return keys
+}
+
+// remindBetween — ключи byRemind с at в (from, to], по возрастанию (at, id).
+func (ix *index) remindBetween(from, to time.Time) []dueKey {
+	lo := sort.Search(len(ix.byRemind), func(i int) bool { return ix.byRemind[i].at.After(from) })
+	hi := sort.Search(len(ix.byRemind), func(i int) bool { return ix.byRemind[i].at.After(to) })
+	if lo >= hi {
+		return nil
+	}
+	return ix.byRemind[lo:hi]
+}
+
+// dueBefore — ID задач со сроком строго раньше t (бинарный поиск по byDue).
+func (ix *index) dueBefore(t time.Time) idSet {
+	n := sort.Search(len(ix.byDue), func(i int) bool { return !ix.byDue[i].at.Before(t) })
+	out := make(idSet, n)
+	for _, k := range ix.byDue[:n] {
+		out[k.id] = struct{}{}
+	}
+	return out
+}
+
+// priorityBetween — ID задач с приоритетом в [min, max].
+func (ix *index) priorityBetween(min, max Priority) idSet {
+	out := make(idSet)
+	for p := min; p <= max; p++ {
+		for id := range ix.byPriority[p] {
+			out[id] = struct{}{}
+		}
+	}
+	return out
+}
+
+// withLabels — ID задач, у которых есть все перечисленные метки.
+// Начинаем с самого маленького множества и пересекаем с остальными.
+func (ix *index) withLabels(labels []string) idSet {
+	sets := make([]idSet, 0, len(labels))
+	for _, l := range labels {
+		set := ix.byLabel[l]
+		if len(set) == 0 {
+			return idSet{}
+		}
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+	out := make(idSet, len(sets[0]))
+	for id := range sets[0] {
+		out[id] = struct{}{}
+	}
+	for _, set := range sets[1:] {
+		for id := range out {
+			if _, ok := set[id]; !ok {
+				delete(out, id)
+			}
+		}
+	}
+	return out
+}
@@ -1,11 +1,166 @@
 package task
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
 
 type Task struct {
-	ID        int64     `json:"id"`
-	Title     string    `json:"title"`
-	Done      bool      `json:"done"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           int64      `json:"id"`
+	ParentID     int64      `json:"parent_id,omitempty"` // 0 — задача верхнего уровня
+	Title        string     `json:"title"`
+	Done         bool       `json:"done"`
+	DueAt        *time.Time `json:"due_at,omitempty"`
+	Priority     Priority   `json:"priority"`
+	Labels       []string   `json:"labels,omitempty"`
+	Recurrence   *Rule      `json:"recurrence,omitempty"`
+	RemindBefore Duration   `json:"remind_before,omitempty"` // за сколько до due_at напомнить
+	NextID       int64      `json:"next_id,omitempty"`       // следующая задача серии, создаётся Scheduler'ом
+	Progress     *float64   `json:"progress,omitempty"`      // доля выполненных подзадач; только у задач с подзадачами
+	Version      int64      `json:"version"`                 // растёт на каждое изменение, отдаётся как ETag
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Fields — изменяемые поля задачи: то, что приходит в POST и PUT.
+type Fields struct {
+	Title        string
+	ParentID     int64
+	Done         bool
+	DueAt        *time.Time
+	Priority     Priority
+	Labels       []string
+	Recurrence   *Rule
+	RemindBefore Duration
+}
+
+// Priority сериализуется строкой: low, normal, high, urgent.
+// Нулевое значение при создании задачи превращается в normal.
+type Priority int
+
+const (
+	PriorityLow Priority = iota + 1
+	PriorityNormal
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:    "low",
+	PriorityNormal: "normal",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+func ParsePriority(s string) (Priority, error) {
+	for p, name := range priorityNames {
+		if strings.EqualFold(s, name) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority %q: want low, normal, high or urgent", s)
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return "normal"
+}
+
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Priority) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.New("priority must be a string")
+	}
+	v, err := ParsePriority(s)
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// Duration сериализуется строкой в формате time.ParseDuration: "30m", "1h30m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.New("duration must be a string like \"30m\" or \"1h30m\"")
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+const (
+	maxLabels   = 20
+	maxLabelLen = 50
+)
+
+// normalize проверяет поля и приводит их к каноническому виду:
+// метки в нижнем регистре, без повторов, по алфавиту.
+func (f *Fields) normalize() error {
+	f.Title = strings.TrimSpace(f.Title)
+	if f.Title == "" {
+		return errors.New("title is required")
+	}
+	if f.ParentID < 0 {
+		return errors.New("parent_id must be positive")
+	}
+	if f.Priority == 0 {
+		f.Priority = PriorityNormal
+	}
+	if f.DueAt != nil {
+		due := f.DueAt.UTC()
+		f.DueAt = &due
+	}
+	if len(f.Labels) > maxLabels {
+		return fmt.Errorf("too many labels: max %d", maxLabels)
+	}
+	seen := make(map[string]bool, len(f.Labels))
+	labels := make([]string, 0, len(f.Labels))
+	for _, l := range f.Labels {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if l == "" || len(l) > maxLabelLen || strings.Contains(l, ",") {
+			return fmt.Errorf("invalid label %q", l)
+		}
+		if !seen[l] {
+			seen[l] = true
+			labels = append(labels, l)
+		}
+	}
+	sort.Strings(labels)
+	f.Labels = labels
+
+	if f.RemindBefore < 0 {
+		return errors.New("remind_before must not be negative")
+	}
+	if f.RemindBefore > 0 && f.DueAt == nil && f.Recurrence == nil {
+		return errors.New("remind_before requires due_at")
+	}
+	// ежемесячное правило без BYMONTHDAY привязываем ко дню срока,
+	// иначе после 31 января → 28 февраля серия «съезжала» бы на 28-е
+	if r := f.Recurrence; r != nil && r.Freq == Monthly && r.ByMonthDay == 0 && f.DueAt != nil {
+		cp := *r
+		cp.ByMonthDay = f.DueAt.Day()
+		f.Recurrence = &cp
+	}
+	return nil
 }
@@ -0,0 +1,160 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrQueueFull = errors.New("notify queue is full")
+
+// Reminder — событие «скоро срок задачи».
+type Reminder struct {
+	TaskID   int64     `json:"task_id"`
+	Title    string    `json:"title"`
+	DueAt    time.Time `json:"due_at"`
+	RemindAt time.Time `json:"remind_at"`
+}
+
+// Notifier доставляет напоминания. Scheduler вызывает его последовательно
+// прямо из тика, поэтому медленную доставку оборачивают в QueueNotifier.
+type Notifier interface {
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// LogNotifier пишет напоминания в лог.
+type LogNotifier struct {
+	Logger *log.Logger // nil — стандартный логгер
+}
+
+func (n LogNotifier) Notify(_ context.Context, r Reminder) error {
+	l := n.Logger
+	if l == nil {
+		l = log.Default()
+	}
+	l.Printf("reminder: task %d %q is due at %s", r.TaskID, r.Title, r.DueAt.Format(time.RFC3339))
+	return nil
+}
+
+// QueueNotifier доставляет напоминания в фоне: Notify только кладёт
+// напоминание в очередь ограниченного размера и сразу возвращается,
+// а отправляют его через Next Workers горутин, запущенных Run.
+// Если очередь заполнена (получатель не успевает или недоступен),
+// Notify возвращает ErrQueueFull — тик не ждёт, а Scheduler откладывает
+// напоминание до следующего тика.
+type QueueNotifier struct {
+	Next    Notifier
+	Workers int // сколько отправок идёт параллельно; меньше 1 — одна
+	queue   chan Reminder
+}
+
+func NewQueueNotifier(next Notifier, size int) *QueueNotifier {
+	return &QueueNotifier{Next: next, Workers: 2, queue: make(chan Reminder, size)}
+}
+
+func (q *QueueNotifier) Notify(ctx context.Context, r Reminder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case q.queue <- r:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run отправляет напоминания из очереди до отмены ctx и возвращает те, что
+// отправить не успел: оставшиеся в очереди и прерванные отменой. Их нужно
+// вернуть планировщику (Scheduler.Requeue), чтобы они не потерялись.
+func (q *QueueNotifier) Run(ctx context.Context) []Reminder {
+	workers := max(q.Workers, 1)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		unsent []Reminder
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case r := <-q.queue:
+					err := q.Next.Notify(ctx, r)
+					if err != nil && ctx.Err() != nil {
+						mu.Lock()
+						unsent = append(unsent, r)
+						mu.Unlock()
+					} else if err != nil {
+						log.Printf("notify: reminder for task %d: %v", r.TaskID, err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for {
+		select {
+		case r := <-q.queue:
+			unsent = append(unsent, r)
+		default:
+			return unsent
+		}
+	}
+}
+
+// WebhookNotifier отправляет напоминание POST-запросом с JSON-телом Reminder.
+// Ответ не 2xx или сетевая ошибка повторяются до Retries раз с растущей паузой.
+type WebhookNotifier struct {
+	URL     string
+	Client  *http.Client
+	Retries int
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}, Retries: 3}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, r Reminder) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		err = n.post(ctx, body)
+		if err == nil || attempt >= n.Retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		}
+	}
+}
+
+func (n *WebhookNotifier) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s: %s", n.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query — фильтры и сортировка для Repo.Find. Нулевые поля не фильтруют.
+type Query struct {
+	Done        *bool
+	DueBefore   *time.Time
+	Overdue     bool // срок прошёл и задача не выполнена
+	MinPriority Priority
+	MaxPriority Priority
+	Labels      []string // задача должна иметь все метки
+	Sort        []SortKey
+	Now         time.Time // «сейчас» для Overdue
+}
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+var sortFields = map[string]func(a, b *Task) int{
+	"id":         func(a, b *Task) int { return cmpInt(a.ID, b.ID) },
+	"title":      func(a, b *Task) int { return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)) },
+	"done":       func(a, b *Task) int { return cmpBool(a.Done, b.Done) },
+	"priority":   func(a, b *Task) int { return cmpInt(int64(a.Priority), int64(b.Priority)) },
+	"due_at":     cmpDue,
+	"created_at": func(a, b *Task) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated_at": func(a, b *Task) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+}
+
+// ParseQuery разбирает параметры GET /api/tasks:
+//
+//	done=true|false
+//	due_before=2025-01-31 или RFC3339
+//	overdue=true
+//	priority=high, priority>=high, priority<=normal
+//	label=a,b (можно повторять параметр; нужны все метки)
+//	sort=priority,-due_at (по умолчанию id; «-» — по убыванию)
+//
+// В строке запроса "priority>=high" разбирается как ключ "priority>" со значением "high".
+func ParseQuery(v url.Values, now time.Time) (Query, error) {
+	q := Query{MinPriority: PriorityLow, MaxPriority: PriorityUrgent, Now: now}
+
+	if raw := v.Get("done"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return q, errors.New("done must be true or false")
+		}
+		q.Done = &b
+	}
+	if raw := v.Get("due_before"); raw != "" {
+		t, err := parseTime(raw)
+		if err != nil {
+			return q, errors.New("due_before must be a date (2006-01-02) or RFC3339 time")
+		}
+		q.DueBefore = &t
+	}
+	if raw := v.Get("overdue"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return q, errors.New("overdue must be true or false")
+		}
+		q.Overdue = b
+	}
+
+	// priority — точное значение, priority>= и priority<= — границы диапазона;
+	// точное значение вместе с границей неоднозначно, такой запрос отклоняется
+	if v.Get("priority") != "" && (v.Get("priority>") != "" || v.Get("priority<") != "") {
+		return q, errors.New("priority cannot be combined with priority>= or priority<=")
+	}
+	for _, f := range []struct {
+		key string
+		set func(Priority)
+	}{
+		{"priority", func(p Priority) { q.MinPriority, q.MaxPriority = p, p }},
+		{"priority>", func(p Priority) { q.MinPriority = p }},
+		{"priority<", func(p Priority) { q.MaxPriority = p }},
+	} {
+		if raw := v.Get(f.key); raw != "" {
+			p, err := ParsePriority(raw)
+			if err != nil {
+				return q, err
+			}
+			f.set(p)
+		}
+	}
+
+	for _, raw := range v["label"] {
+		for _, l := range strings.Split(raw, ",") {
+			if l = strings.ToLower(strings.TrimSpace(l)); l != "" {
+				q.Labels = append(q.Labels, l)
+			}
+		}
+	}
+
+	if raw := v.Get("sort"); raw != "" {
+		for _, f := range strings.Split(raw, ",") {
+			f = strings.TrimSpace(f)
+			key := SortKey{Field: strings.TrimPrefix(f, "-"), Desc: strings.HasPrefix(f, "-")}
+			if _, ok := sortFields[key.Field]; !ok {
+				return q, fmt.Errorf("unknown sort field %q", key.Field)
+			}
+			q.Sort = append(q.Sort, key)
+		}
+	}
+	return q, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// Find выбирает задачи по запросу. Сначала по индексам набирается множество
+// кандидатов (пересечение меток, диапазона приоритетов и сроков), затем
+// кандидаты проверяются полным условием и сортируются — сортируется только
+// результат, а не всё хранилище.
+func (r *Repo) Find(q Query) []*Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var cand idSet // nil — все задачи
+	narrow := func(s idSet) {
+		if cand == nil {
+			cand = s
+			return
+		}
+		if len(s) < len(cand) {
+			cand, s = s, cand
+		}
+		for id := range cand {
+			if _, ok := s[id]; !ok {
+				delete(cand, id)
+			}
+		}
+	}
+	if len(q.Labels) > 0 {
+		narrow(r.ix.withLabels(q.Labels))
+	}
+	if q.MinPriority > PriorityLow || q.MaxPriority < PriorityUrgent {
+		narrow(r.ix.priorityBetween(q.MinPriority, q.MaxPriority))
+	}
+	if q.DueBefore != nil {
+		narrow(r.ix.dueBefore(*q.DueBefore))
+	}
+	if q.Overdue {
+		narrow(r.ix.dueBefore(q.Now))
+	}
+
+	out := make([]*Task, 0)
+	if cand == nil {
+		for _, t := range r.items {
+			if q.match(t) {
+				out = append(out, r.view(t))
+			}
+		}
+	} else {
+		for id := range cand {
+			if t := r.items[id]; t != nil && q.match(t) {
+				out = append(out, r.view(t))
+			}
+		}
+	}
+	sortTasks(out, q.Sort)
+	return out
+}
+
+func (q Query) match(t *Task) bool {
+	if q.Done != nil && t.Done != *q.Done {
+		return false
+	}
+	if q.Overdue && (t.Done || t.DueAt == nil || !t.DueAt.Before(q.Now)) {
+		return false
+	}
+	if q.DueBefore != nil && (t.DueAt == nil || !t.DueAt.Before(*q.DueBefore)) {
+		return false
+	}
+	if t.Priority < q.MinPriority || t.Priority > q.MaxPriority {
+		return false
+	}
+	for _, l := range q.Labels {
+		if !hasLabel(t, l) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortTasks сортирует по ключам по очереди; последним всегда идёт id,
+// чтобы порядок был детерминированным.
+func sortTasks(ts []*Task, keys []SortKey) {
+	keys = append(keys, SortKey{Field: "id"})
+	sort.Slice(ts, func(i, j int) bool {
+		for _, k := range keys {
+			if k.Field == "due_at" && (ts[i].DueAt == nil) != (ts[j].DueAt == nil) {
+				return ts[j].DueAt == nil // без срока — в конце при любом направлении
+			}
+			c := sortFields[k.Field](ts[i], ts[j])
+			if c == 0 {
+				continue
+			}
+			if k.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+func hasLabel(t *Task, l string) bool {
+	i := sort.SearchStrings(t.Labels, l)
+	return i < len(t.Labels) && t.Labels[i] == l
+}
+
+// cmpDue сравнивает сроки; случай «у одной задачи срока нет» разбирает sortTasks.
+func cmpDue(a, b *Task) int {
+	if a.DueAt == nil || b.DueAt == nil {
+		return 0
+	}
+	return a.DueAt.Compare(*b.DueAt)
+}
+
+func cmpInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func cmpBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case !a:
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,216 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Freq int
+
+const (
+	Daily Freq = iota + 1
+	Weekly
+	Monthly
+)
+
+var freqNames = map[Freq]string{Daily: "DAILY", Weekly: "WEEKLY", Monthly: "MONTHLY"}
+
+// Rule — правило повторения задачи, подмножество RRULE (RFC 5545):
+//
+//	FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL=n, BYDAY=MO,WE (для WEEKLY),
+//	BYMONTHDAY=1..31 (для MONTHLY), UNTIL=20251231T000000Z
+//
+// Вместо RRULE можно написать просто "daily", "weekly" или "monthly".
+// В JSON правило — строка. После разбора Rule не меняется, поэтому
+// задачи могут делить один указатель.
+type Rule struct {
+	Freq       Freq
+	Interval   int            // каждые n дней/недель/месяцев, не меньше 1
+	ByDay      []time.Weekday // дни недели, с понедельника
+	ByMonthDay int            // день месяца; если месяц короче — последний день
+	Until      *time.Time     // последнее допустимое повторение
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+const untilLayout = "20060102T150405Z"
+
+func ParseRule(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "daily":
+		return &Rule{Freq: Daily, Interval: 1}, nil
+	case "weekly":
+		return &Rule{Freq: Weekly, Interval: 1}, nil
+	case "monthly":
+		return &Rule{Freq: Monthly, Interval: 1}, nil
+	}
+
+	r := &Rule{Interval: 1}
+	body := strings.TrimPrefix(strings.ToUpper(s), "RRULE:")
+	for _, part := range strings.Split(body, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("invalid recurrence %q: want daily, weekly, monthly or FREQ=...", s)
+		}
+		switch key {
+		case "FREQ":
+			for f, name := range freqNames {
+				if name == val {
+					r.Freq = f
+				}
+			}
+			if r.Freq == 0 {
+				return nil, fmt.Errorf("unsupported FREQ %q: want DAILY, WEEKLY or MONTHLY", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > 366 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			r.Interval = n
+		case "BYDAY":
+			seen := make(map[time.Weekday]bool)
+			for _, d := range strings.Split(val, ",") {
+				wd, ok := weekdays[d]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY %q", d)
+				}
+				if !seen[wd] {
+					seen[wd] = true
+					r.ByDay = append(r.ByDay, wd)
+				}
+			}
+			sort.Slice(r.ByDay, func(i, j int) bool { return fromMonday(r.ByDay[i]) < fromMonday(r.ByDay[j]) })
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > 31 {
+				return nil, fmt.Errorf("invalid BYMONTHDAY %q", val)
+			}
+			r.ByMonthDay = n
+		case "UNTIL":
+			t, err := time.Parse(untilLayout, val)
+			if err != nil {
+				if t, err = time.Parse("20060102", val); err != nil {
+					return nil, fmt.Errorf("invalid UNTIL %q: want 20060102 or 20060102T150405Z", val)
+				}
+			}
+			r.Until = &t
+		default:
+			return nil, fmt.Errorf("unsupported recurrence part %q", key)
+		}
+	}
+	switch {
+	case r.Freq == 0:
+		return nil, errors.New("recurrence: FREQ is required")
+	case len(r.ByDay) > 0 && r.Freq != Weekly:
+		return nil, errors.New("recurrence: BYDAY is only supported with FREQ=WEEKLY")
+	case r.ByMonthDay != 0 && r.Freq != Monthly:
+		return nil, errors.New("recurrence: BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return r, nil
+}
+
+// String — каноническая запись RRULE.
+func (r *Rule) String() string {
+	var b strings.Builder
+	b.WriteString("FREQ=" + freqNames[r.Freq])
+	if r.Interval > 1 {
+		b.WriteString(";INTERVAL=" + strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			days = append(days, strings.ToUpper(d.String()[:2]))
+		}
+		b.WriteString(";BYDAY=" + strings.Join(days, ","))
+	}
+	if r.ByMonthDay != 0 {
+		b.WriteString(";BYMONTHDAY=" + strconv.Itoa(r.ByMonthDay))
+	}
+	if r.Until != nil {
+		b.WriteString(";UNTIL=" + r.Until.UTC().Format(untilLayout))
+	}
+	return b.String()
+}
+
+func (r *Rule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+func (r *Rule) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.New("recurrence must be a string")
+	}
+	parsed, err := ParseRule(s)
+	if err != nil {
+		return err
+	}
+	*r = *parsed
+	return nil
+}
+
+// Next — первое повторение строго после from; время суток берётся из from.
+// false — серия закончилась (следующее повторение позже UNTIL).
+func (r *Rule) Next(from time.Time) (time.Time, bool) {
+	var next time.Time
+	switch r.Freq {
+	case Daily:
+		next = from.AddDate(0, 0, r.Interval)
+	case Weekly:
+		next = r.nextWeekly(from)
+	case Monthly:
+		next = r.nextMonthly(from)
+	}
+	if r.Until != nil && next.After(*r.Until) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// nextWeekly: сначала оставшиеся дни BYDAY этой недели (неделя с понедельника),
+// затем первый день BYDAY через Interval недель.
+func (r *Rule) nextWeekly(from time.Time) time.Time {
+	if len(r.ByDay) == 0 {
+		return from.AddDate(0, 0, 7*r.Interval)
+	}
+	weekStart := from.AddDate(0, 0, -fromMonday(from.Weekday()))
+	for _, d := range r.ByDay {
+		if c := weekStart.AddDate(0, 0, fromMonday(d)); c.After(from) {
+			return c
+		}
+	}
+	return weekStart.AddDate(0, 0, 7*r.Interval+fromMonday(r.ByDay[0]))
+}
+
+func (r *Rule) nextMonthly(from time.Time) time.Time {
+	day := r.ByMonthDay
+	if day == 0 {
+		day = from.Day()
+	}
+	if c := monthDay(from, 0, day); c.After(from) {
+		return c
+	}
+	return monthDay(from, r.Interval, day)
+}
+
+// monthDay — день day месяца, отстоящего от t на add месяцев, с временем суток t.
+// Для коротких месяцев день обрезается до последнего (31 → 30, 28 или 29).
+func monthDay(t time.Time, add, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(add), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, last)-1)
+}
+
+func fromMonday(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
@@ -0,0 +1,158 @@
+package task
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in, want string // want == "" — ошибка разбора
+	}{
+		{"daily", "FREQ=DAILY"},
+		{" Weekly ", "FREQ=WEEKLY"},
+		{"RRULE:FREQ=DAILY;INTERVAL=3", "FREQ=DAILY;INTERVAL=3"},
+		{"freq=weekly;byday=fr,mo,mo", "FREQ=WEEKLY;BYDAY=MO,FR"},
+		{"FREQ=MONTHLY;BYMONTHDAY=31;UNTIL=20251231", "FREQ=MONTHLY;BYMONTHDAY=31;UNTIL=20251231T000000Z"},
+		{"FREQ=DAILY;UNTIL=20250301T120000Z", "FREQ=DAILY;UNTIL=20250301T120000Z"},
+		{"FREQ=YEARLY", ""},
+		{"INTERVAL=2", ""},
+		{"FREQ=DAILY;INTERVAL=0", ""},
+		{"FREQ=DAILY;BYDAY=MO", ""},
+		{"FREQ=WEEKLY;BYDAY=XX", ""},
+		{"FREQ=WEEKLY;BYMONTHDAY=1", ""},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", ""},
+		{"FREQ=DAILY;UNTIL=tomorrow", ""},
+		{"FREQ=DAILY;COUNT=3", ""},
+		{"hourly", ""},
+	}
+	for _, tt := range tests {
+		r, err := ParseRule(tt.in)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("ParseRule(%q) = %s; want error", tt.in, r)
+		case tt.want != "" && err != nil:
+			t.Errorf("ParseRule(%q): %v", tt.in, err)
+		case tt.want != "" && r.String() != tt.want:
+			t.Errorf("ParseRule(%q) = %s; want %s", tt.in, r, tt.want)
+		}
+	}
+}
+
+func TestRuleJSON(t *testing.T) {
+	var v struct {
+		R *Rule `json:"recurrence"`
+	}
+	if err := json.Unmarshal([]byte(`{"recurrence":"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) != `{"recurrence":"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU"}` {
+		t.Fatalf("round trip = %s, %v", b, err)
+	}
+	if err := json.Unmarshal([]byte(`{"recurrence":{"freq":"daily"}}`), &v); err == nil {
+		t.Fatal("object recurrence: want error")
+	}
+}
+
+func TestRuleNext(t *testing.T) {
+	// 2025-03-14 — пятница
+	at := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 30, 0, 0, time.UTC) }
+	tests := []struct {
+		rule string
+		from time.Time
+		want []time.Time // последовательные повторения; после них серия закончилась, если end
+		end  bool
+	}{
+		{"daily", at(2025, 3, 14), []time.Time{at(2025, 3, 15), at(2025, 3, 16)}, false},
+		{"FREQ=DAILY;INTERVAL=10", at(2025, 2, 25), []time.Time{at(2025, 3, 7), at(2025, 3, 17)}, false},
+		{"weekly", at(2025, 3, 14), []time.Time{at(2025, 3, 21), at(2025, 3, 28)}, false},
+		{"FREQ=WEEKLY;BYDAY=MO,WE,FR", at(2025, 3, 12), []time.Time{at(2025, 3, 14), at(2025, 3, 17), at(2025, 3, 19)}, false},
+		// неделя начинается с понедельника: с воскресенья — в понедельник через INTERVAL недель
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU", at(2025, 3, 10), []time.Time{at(2025, 3, 16), at(2025, 3, 24), at(2025, 3, 30)}, false},
+		{"monthly", at(2025, 1, 15), []time.Time{at(2025, 2, 15), at(2025, 3, 15)}, false},
+		// BYMONTHDAY=31 в коротких месяцах — последний день, в длинных снова 31
+		{"FREQ=MONTHLY;BYMONTHDAY=31", at(2025, 1, 31), []time.Time{at(2025, 2, 28), at(2025, 3, 31), at(2025, 4, 30)}, false},
+		{"FREQ=MONTHLY;BYMONTHDAY=29", at(2024, 1, 29), []time.Time{at(2024, 2, 29), at(2024, 3, 29)}, false},
+		{"FREQ=MONTHLY;BYMONTHDAY=20", at(2025, 3, 14), []time.Time{at(2025, 3, 20), at(2025, 4, 20)}, false},
+		{"FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1", at(2025, 3, 14), []time.Time{at(2025, 6, 1), at(2025, 9, 1)}, false},
+		{"FREQ=MONTHLY;INTERVAL=12", at(2025, 3, 14), []time.Time{at(2026, 3, 14)}, false},
+		// UNTIL включительно
+		{"FREQ=DAILY;UNTIL=20250316T093000Z", at(2025, 3, 14), []time.Time{at(2025, 3, 15), at(2025, 3, 16)}, true},
+		{"FREQ=WEEKLY;UNTIL=20250320", at(2025, 3, 14), nil, true},
+	}
+	for _, tt := range tests {
+		r, err := ParseRule(tt.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.rule, err)
+		}
+		from := tt.from
+		for i, want := range tt.want {
+			got, ok := r.Next(from)
+			if !ok || !got.Equal(want) {
+				t.Fatalf("%s: occurrence %d after %s = %s, %v; want %s", tt.rule, i+1, from, got, ok, want)
+			}
+			from = got
+		}
+		if _, ok := r.Next(from); ok == tt.end {
+			t.Fatalf("%s: after %s ok = %v; want series end = %v", tt.rule, from, ok, tt.end)
+		}
+	}
+}
+
+func TestSpawnNextOccurrence(t *testing.T) {
+	repo := NewRepo()
+	due := time.Date(2025, 1, 31, 18, 0, 0, 0, time.UTC)
+	rule, _ := ParseRule("FREQ=MONTHLY;BYMONTHDAY=31;UNTIL=20250401")
+	f := Fields{Title: "report", DueAt: &due, Labels: []string{"work"}, Recurrence: rule, RemindBefore: Duration(time.Hour)}
+	if _, err := repo.Create(f); err != nil {
+		t.Fatal(err)
+	}
+	// выполненная задача серии ждёт планировщика
+	f.Done = true
+	if _, err := repo.Update(1, f, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ids := repo.pendingIDs(); !equalIDs(ids, []int64{1}) {
+		t.Fatalf("pending = %v; want [1]", ids)
+	}
+
+	// пропущенное повторение (28 февраля) не создаётся — берётся первое впереди
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	next, err := repo.spawnNext(1, now)
+	if err != nil || next == nil {
+		t.Fatalf("spawnNext = %v, %v", next, err)
+	}
+	want := time.Date(2025, 3, 31, 18, 0, 0, 0, time.UTC)
+	if next.Done || next.DueAt == nil || !next.DueAt.Equal(want) || next.Title != "report" ||
+		next.RemindBefore != f.RemindBefore || len(next.Labels) != 1 || next.Recurrence != rule {
+		t.Fatalf("next = %+v; want undone copy due %s", next, want)
+	}
+	if done, _ := repo.Get(1); done.NextID != next.ID || done.Version != 3 {
+		t.Fatalf("done task = NextID %d v%d; want NextID %d v3", done.NextID, done.Version, next.ID)
+	}
+
+	// снять и снова поставить done — второе продолжение не создаётся
+	f.Done = false
+	if _, err := repo.Update(1, f, nil); err != nil {
+		t.Fatal(err)
+	}
+	f.Done = true
+	if _, err := repo.Update(1, f, nil); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := repo.spawnNext(1, now); again != nil || err != nil || len(repo.List()) != 2 {
+		t.Fatalf("second spawn = %v, %v; tasks = %d", again, err, len(repo.List()))
+	}
+
+	// следующее повторение (30 апреля) позже UNTIL — серия закончилась
+	nf := f
+	nf.DueAt = next.DueAt
+	if _, err := repo.Update(next.ID, nf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if last, err := repo.spawnNext(next.ID, now); last != nil || err != nil || len(repo.List()) != 2 {
+		t.Fatalf("series past UNTIL spawned %v, %v", last, err)
+	}
+}
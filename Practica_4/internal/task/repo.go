@@ -2,20 +2,48 @@ package task
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 )
 
-var ErrNotFound = errors.New("task not found")
+var (
+	ErrNotFound           = errors.New("task not found")
+	ErrPreconditionFailed = errors.New("task version does not match")
+	ErrInvalid            = errors.New("invalid task")
+	ErrHasChildren        = errors.New("task has subtasks")
+	ErrCycle              = errors.New("parent_id would create a cycle")
+)
+
+// Match проверяет текущую версию задачи (If-Match); nil — без условия.
+type Match func(version int64) bool
 
 type Repo struct {
 	mu    sync.RWMutex
 	seq   int64
 	items map[int64]*Task
+	ix    index
+	// pending — выполненные повторяющиеся задачи, для которых Scheduler
+	// ещё не создал следующую; wake будит Scheduler, не дожидаясь тика.
+	pending idSet
+	wake    chan struct{}
+	events  *Broker // получает событие после каждого изменения
 }
 
+const eventBuffer = 1024
+
 func NewRepo() *Repo {
-	return &Repo{items: make(map[int64]*Task)}
+	return &Repo{
+		items:   make(map[int64]*Task),
+		ix:      newIndex(),
+		pending: make(idSet),
+		wake:    make(chan struct{}, 1),
+		events:  NewBroker(eventBuffer),
+	}
+}
+
+func (r *Repo) Events() *Broker {
+	return r.events
 }
 
 func (r *Repo) List() []*Task {
@@ -23,7 +51,7 @@ func (r *Repo) List() []*Task {
 	defer r.mu.RUnlock()
 	out := make([]*Task, 0, len(r.items))
 	for _, t := range r.items {
-		out = append(out, t)
+		out = append(out, r.view(t))
 	}
 	return out
 }
@@ -35,38 +63,190 @@ func (r *Repo) Get(id int64) (*Task, error) {
 	if !ok {
 		return nil, ErrNotFound
 	}
-	return t, nil
+	return r.view(t), nil
 }
 
-func (r *Repo) Create(title string) *Task {
+func (r *Repo) Create(f Fields) (*Task, error) {
+	if err := f.normalize(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if err := r.checkParent(0, f.ParentID); err != nil {
+		return nil, err
+	}
 	r.seq++
 	now := time.Now()
-	t := &Task{ID: r.seq, Title: title, CreatedAt: now, UpdatedAt: now, Done: false}
+	t := &Task{ID: r.seq, CreatedAt: now, UpdatedAt: now, Version: 1}
+	t.set(f)
 	r.items[t.ID] = t
-	return t
+	r.ix.add(t)
+	r.events.Publish(EventCreated, r.view(t))
+	r.parentChanged(t.ParentID)
+	return r.view(t), nil
 }
 
-func (r *Repo) Update(id int64, title string, done bool) (*Task, error) {
+// Update меняет задачу, если match(текущая версия) истинно; иначе ErrPreconditionFailed.
+// Проверка и запись идут под одной блокировкой.
+func (r *Repo) Update(id int64, f Fields, match Match) (*Task, error) {
+	if err := f.normalize(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	t, ok := r.items[id]
 	if !ok {
 		return nil, ErrNotFound
 	}
-	t.Title = title
-	t.Done = done
+	if match != nil && !match(t.Version) {
+		return nil, ErrPreconditionFailed
+	}
+	if err := r.checkParent(id, f.ParentID); err != nil {
+		return nil, err
+	}
+	wasDone, oldParent := t.Done, t.ParentID
+	r.ix.remove(t)
+	t.set(f)
 	t.UpdatedAt = time.Now()
-	return t, nil
+	t.Version++
+	r.ix.add(t)
+	r.events.Publish(EventUpdated, r.view(t))
+	if wasDone != t.Done || oldParent != t.ParentID {
+		r.parentChanged(oldParent)
+		if oldParent != t.ParentID {
+			r.parentChanged(t.ParentID)
+		}
+	}
+	if t.Done && !wasDone && t.Recurrence != nil && t.NextID == 0 {
+		r.pending[t.ID] = struct{}{}
+		select {
+		case r.wake <- struct{}{}:
+		default: // Scheduler уже разбужен
+		}
+	}
+	return r.view(t), nil
 }
 
-func (r *Repo) Delete(id int64) error {
+// Delete удаляет задачу. Задачу с подзадачами без cascade удалить нельзя
+// (ErrHasChildren); с cascade удаляется всё поддерево, потомки — раньше предков.
+func (r *Repo) Delete(id int64, match Match, cascade bool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, ok := r.items[id]; !ok {
+	t, ok := r.items[id]
+	if !ok {
 		return ErrNotFound
 	}
-	delete(r.items, id)
+	if match != nil && !match(t.Version) {
+		return ErrPreconditionFailed
+	}
+	if len(r.ix.byParent[id]) > 0 && !cascade {
+		return ErrHasChildren
+	}
+	for _, d := range r.subtree(id) {
+		r.ix.remove(d)
+		delete(r.items, d.ID)
+		delete(r.pending, d.ID)
+		r.events.Publish(EventDeleted, clone(d))
+	}
+	r.parentChanged(t.ParentID)
 	return nil
 }
+
+// pendingIDs — задачи, ожидающие создания следующего повторения.
+func (r *Repo) pendingIDs() []int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]int64, 0, len(r.pending))
+	for id := range r.pending {
+		out = append(out, id)
+	}
+	return out
+}
+
+// spawnNext создаёт следующую задачу серии для выполненной задачи id.
+// Всё делается под одной блокировкой, а NextID у исходной задачи
+// не даёт создать продолжение второй раз. nil, nil — создавать нечего
+// (задачу уже удалили, вернули в работу или серия закончилась по UNTIL).
+func (r *Repo) spawnNext(id int64, now time.Time) (*Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, id)
+	t, ok := r.items[id]
+	if !ok || !t.Done || t.Recurrence == nil || t.NextID != 0 {
+		return nil, nil
+	}
+
+	// от срока, если он есть; пропущенные повторения не создаём —
+	// берём первое, которое ещё впереди
+	base := now
+	if t.DueAt != nil {
+		base = *t.DueAt
+	}
+	due, ok := t.Recurrence.Next(base)
+	for ok && !due.After(now) {
+		due, ok = t.Recurrence.Next(due)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	f := Fields{
+		Title:        t.Title,
+		ParentID:     t.ParentID,
+		DueAt:        &due,
+		Priority:     t.Priority,
+		Labels:       append([]string(nil), t.Labels...),
+		Recurrence:   t.Recurrence,
+		RemindBefore: t.RemindBefore,
+	}
+	if err := f.normalize(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	r.seq++
+	next := &Task{ID: r.seq, CreatedAt: now, UpdatedAt: now, Version: 1}
+	next.set(f)
+	r.items[next.ID] = next
+	r.ix.add(next)
+	r.events.Publish(EventCreated, r.view(next))
+
+	t.NextID = next.ID
+	t.UpdatedAt = now
+	t.Version++
+	r.events.Publish(EventUpdated, r.view(t))
+	r.parentChanged(t.ParentID)
+	return r.view(next), nil
+}
+
+// reminders — невыполненные задачи, время напоминания (due_at − remind_before)
+// которых попадает в полуинтервал (from, to], по возрастанию этого времени.
+// Идёт по индексу byRemind (бинарный поиск), а не по всем задачам: тик
+// планировщика стоит столько, сколько напоминаний пришлось на интервал.
+func (r *Repo) reminders(from, to time.Time) []Reminder {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := r.ix.remindBetween(from, to)
+	out := make([]Reminder, 0, len(keys))
+	for _, k := range keys {
+		t := r.items[k.id]
+		out = append(out, Reminder{TaskID: t.ID, Title: t.Title, DueAt: *t.DueAt, RemindAt: k.at})
+	}
+	return out
+}
+
+// clone — копия для отдачи наружу, чтобы сериализация не гонялась с Update.
+func clone(t *Task) *Task {
+	cp := *t
+	cp.Labels = append([]string(nil), t.Labels...)
+	return &cp
+}
+
+func (t *Task) set(f Fields) {
+	t.Title = f.Title
+	t.ParentID = f.ParentID
+	t.Done = f.Done
+	t.DueAt = f.DueAt
+	t.Priority = f.Priority
+	t.Labels = f.Labels
+	t.Recurrence = f.Recurrence
+	t.RemindBefore = f.RemindBefore
+}
@@ -0,0 +1,64 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func reminderIDs(rs []Reminder) []int64 {
+	out := make([]int64, 0, len(rs))
+	for _, r := range rs {
+		out = append(out, r.TaskID)
+	}
+	return out
+}
+
+func TestRemindersFollowIndex(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	repo := NewRepo()
+	create := func(due time.Time, before time.Duration) *Task {
+		t.Helper()
+		task, err := repo.Create(Fields{Title: "t", DueAt: &due, RemindBefore: Duration(before)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	// напоминания: 1 — 09:30, 2 — 09:10, 3 — 09:30, 4 — без напоминания, 5 — 11:00
+	create(start.Add(time.Hour), 30*time.Minute)
+	create(start.Add(3*time.Hour), 170*time.Minute)
+	create(start.Add(90*time.Minute), time.Hour)
+	create(start.Add(20*time.Minute), 0)
+	create(start.Add(3*time.Hour), time.Hour)
+
+	got := repo.reminders(start, start.Add(time.Hour))
+	if ids := reminderIDs(got); !equalIDs(ids, []int64{2, 1, 3}) {
+		t.Fatalf("reminders = %v; want [2 1 3] (по времени, затем по ID)", ids)
+	}
+	if !got[0].RemindAt.Equal(start.Add(10*time.Minute)) || !got[0].DueAt.Equal(start.Add(3*time.Hour)) {
+		t.Fatalf("reminder 2 = %+v", got[0])
+	}
+	// границы: from не включается, to включается
+	if ids := reminderIDs(repo.reminders(start.Add(10*time.Minute), start.Add(30*time.Minute))); !equalIDs(ids, []int64{1, 3}) {
+		t.Fatalf("(09:10, 09:30] = %v; want [1 3]", ids)
+	}
+
+	// выполненная задача выпадает из индекса, изменённая переезжает, удалённая исчезает
+	if _, err := repo.Update(1, Fields{Title: "t", Done: true, DueAt: ptr(start.Add(time.Hour)), RemindBefore: Duration(30 * time.Minute)}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Update(5, Fields{Title: "t", DueAt: ptr(start.Add(time.Hour)), RemindBefore: Duration(5 * time.Minute)}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(3, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if ids := reminderIDs(repo.reminders(start, start.Add(time.Hour))); !equalIDs(ids, []int64{2, 5}) {
+		t.Fatalf("after update/delete = %v; want [2 5]", ids)
+	}
+	if got := repo.reminders(start.Add(time.Hour), start.Add(24*time.Hour)); len(got) != 0 {
+		t.Fatalf("late window = %v; want none", reminderIDs(got))
+	}
+}
+
+func ptr(t time.Time) *time.Time { return &t }
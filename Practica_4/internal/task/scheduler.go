@@ -0,0 +1,181 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Scheduler — фоновая работа с задачами: создаёт следующее повторение
+// выполненных повторяющихся задач и рассылает напоминания.
+//
+// Напоминания отправляются не более одного раза: Scheduler помнит момент
+// последней проверки и за тик отправляет только те, чьё время попало
+// в (последняя проверка, сейчас]. Если задан StateFile, этот момент
+// сохраняется на диск до отправки, поэтому после перезапуска уже
+// отправленные напоминания не повторяются, а пропущенные за время простоя —
+// досылаются.
+//
+// Напоминания, которые Notifier не принял (очередь QueueNotifier заполнена)
+// или не успел отправить до остановки (см. Requeue), не теряются: они
+// остаются в backlog, сохраняются в StateFile и отправляются в следующий тик.
+type Scheduler struct {
+	repo      *Repo
+	notifier  Notifier
+	Interval  time.Duration // период проверки, по умолчанию 30s
+	StateFile string        // пусто — состояние только в памяти
+	Now       func() time.Time
+
+	last    time.Time
+	backlog []Reminder
+}
+
+type schedulerState struct {
+	LastRun time.Time  `json:"last_run"`
+	Backlog []Reminder `json:"backlog,omitempty"`
+}
+
+func NewScheduler(repo *Repo, n Notifier) *Scheduler {
+	return &Scheduler{repo: repo, notifier: n, Interval: 30 * time.Second, Now: time.Now}
+}
+
+// Run работает до отмены ctx и возвращает nil; ошибка — только если
+// не удалось прочитать StateFile при старте.
+func (s *Scheduler) Run(ctx context.Context) error {
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+	s.last, s.backlog = st.LastRun, st.Backlog
+	if s.last.IsZero() {
+		s.last = s.Now()
+	}
+
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		s.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		case <-s.repo.wake:
+		}
+	}
+}
+
+// tick сдвигает s.last на текущий момент и отправляет напоминания.
+func (s *Scheduler) tick(ctx context.Context) {
+	now := s.Now()
+	for _, id := range s.repo.pendingIDs() {
+		next, err := s.repo.spawnNext(id, now)
+		if err != nil {
+			log.Printf("scheduler: task %d: next occurrence: %v", id, err)
+			continue
+		}
+		if next != nil {
+			log.Printf("scheduler: task %d done, created task %d due %s", id, next.ID, next.DueAt.Format(time.RFC3339))
+		}
+	}
+
+	if !now.After(s.last) && len(s.backlog) == 0 {
+		return
+	}
+	due, last := s.backlog, s.last
+	if now.After(last) {
+		due = append(due, s.repo.reminders(last, now)...)
+		last = now
+	}
+	// сначала сохраняем отметку, потом отправляем: лучше потерять
+	// напоминание при падении, чем прислать его дважды
+	if err := s.save(schedulerState{LastRun: last}); err != nil {
+		log.Printf("scheduler: save state: %v", err)
+		return
+	}
+	s.last, s.backlog = last, nil
+
+	var rejected []Reminder
+	for i, r := range due {
+		if ctx.Err() != nil {
+			rejected = append(rejected, due[i:]...)
+			break
+		}
+		if err := s.notifier.Notify(ctx, r); err != nil {
+			log.Printf("scheduler: reminder for task %d: %v", r.TaskID, err)
+			if errors.Is(err, ErrQueueFull) || ctx.Err() != nil {
+				rejected = append(rejected, r)
+			}
+		}
+	}
+	if len(rejected) > 0 {
+		s.keep(rejected)
+	}
+}
+
+// Requeue возвращает в backlog напоминания, которые Notifier принял, но не
+// успел отправить (см. QueueNotifier.Run), и сохраняет их в StateFile.
+// Вызывается после того, как Run вернулся.
+func (s *Scheduler) Requeue(rs []Reminder) {
+	if len(rs) > 0 {
+		s.keep(rs)
+	}
+}
+
+// keep добавляет напоминания в backlog: они уйдут в следующий тик
+// или после перезапуска, если задан StateFile.
+func (s *Scheduler) keep(rs []Reminder) {
+	s.backlog = append(s.backlog, rs...)
+	log.Printf("scheduler: %d reminders postponed", len(rs))
+	if err := s.save(schedulerState{LastRun: s.last, Backlog: s.backlog}); err != nil {
+		log.Printf("scheduler: save state: %v", err)
+	}
+}
+
+func (s *Scheduler) load() (schedulerState, error) {
+	var st schedulerState
+	if s.StateFile == "" {
+		return st, nil
+	}
+	b, err := os.ReadFile(s.StateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	err = json.Unmarshal(b, &st)
+	return st, err
+}
+
+// save пишет состояние атомарно: во временный файл и rename поверх старого.
+func (s *Scheduler) save(st schedulerState) error {
+	if s.StateFile == "" {
+		return nil
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.StateFile), ".scheduler-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.StateFile)
+}
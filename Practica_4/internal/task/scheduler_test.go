@@ -0,0 +1,181 @@
+package task
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder — Notifier, который запоминает отправленные напоминания.
+type recorder struct {
+	mu  sync.Mutex
+	ids []int64
+}
+
+func (n *recorder) Notify(_ context.Context, r Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.ids = append(n.ids, r.TaskID)
+	return nil
+}
+
+func (n *recorder) sent() []int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]int64(nil), n.ids...)
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// withReminders создаёт n задач, напоминания по которым приходятся на
+// (start, start+n минут], по минуте на задачу.
+func withReminders(t *testing.T, repo *Repo, start time.Time, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		due := start.Add(time.Duration(i)*time.Minute + time.Hour)
+		if _, err := repo.Create(Fields{Title: "t", DueAt: &due, RemindBefore: Duration(time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSchedulerSendsOnce(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	repo := NewRepo()
+	withReminders(t, repo, start, 3)
+
+	n := &recorder{}
+	s := NewScheduler(repo, n)
+	s.StateFile = filepath.Join(t.TempDir(), "state.json")
+	now := start
+	s.Now = func() time.Time { return now }
+	s.last = start
+
+	now = start.Add(2 * time.Minute)
+	s.tick(context.Background())
+	now = start.Add(10 * time.Minute)
+	s.tick(context.Background())
+	s.tick(context.Background())
+	if got := n.sent(); !equalIDs(got, []int64{1, 2, 3}) {
+		t.Fatalf("sent = %v; want [1 2 3], each once", got)
+	}
+
+	// после перезапуска уже отправленные не повторяются
+	again := NewScheduler(repo, n)
+	again.StateFile = s.StateFile
+	st, err := again.load()
+	if err != nil || !st.LastRun.Equal(now) {
+		t.Fatalf("state = %+v, %v; want last_run %s", st, err, now)
+	}
+}
+
+// Очередь заполнена — напоминания не теряются: они сохраняются в StateFile
+// и уходят в следующий тик, в том числе после перезапуска.
+func TestSchedulerKeepsRejectedReminders(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	repo := NewRepo()
+	withReminders(t, repo, start, 3)
+	state := filepath.Join(t.TempDir(), "state.json")
+
+	q := NewQueueNotifier(&recorder{}, 1) // Run не запущен: примет одно и заполнится
+	s := NewScheduler(repo, q)
+	s.StateFile = state
+	s.Now = func() time.Time { return start.Add(10 * time.Minute) }
+	s.last = start
+	s.tick(context.Background())
+	if len(q.queue) != 1 {
+		t.Fatalf("queued = %d; want 1", len(q.queue))
+	}
+
+	// «перезапуск»: новый планировщик читает backlog из файла
+	n := &recorder{}
+	restarted := NewScheduler(repo, n)
+	restarted.StateFile = state
+	restarted.Interval = time.Hour
+	restarted.Now = s.Now
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- restarted.Run(ctx) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(n.sent()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := n.sent(); !equalIDs(got, []int64{2, 3}) {
+		t.Fatalf("sent after restart = %v; want the rejected [2 3]", got)
+	}
+	st, err := restarted.load()
+	if err != nil || len(st.Backlog) != 0 {
+		t.Fatalf("state after delivery = %+v, %v; want empty backlog", st, err)
+	}
+}
+
+// Недоставленное до остановки QueueNotifier возвращает, а Requeue сохраняет.
+func TestQueueNotifierReturnsUnsent(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	slow := notifierFunc(func(ctx context.Context, r Reminder) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-block:
+			return nil
+		}
+	})
+	q := NewQueueNotifier(slow, 10)
+	q.Workers = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan []Reminder)
+	go func() { done <- q.Run(ctx) }()
+	for id := int64(1); id <= 3; id++ {
+		if err := q.Notify(ctx, Reminder{TaskID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond) // первое уже отправляется
+	cancel()
+	unsent := <-done
+	if len(unsent) != 3 {
+		t.Fatalf("unsent = %+v; want all 3 (one interrupted, two queued)", unsent)
+	}
+
+	s := NewScheduler(NewRepo(), q)
+	s.StateFile = filepath.Join(t.TempDir(), "state.json")
+	s.Requeue(unsent)
+	st, err := s.load()
+	if err != nil || len(st.Backlog) != 3 {
+		t.Fatalf("state = %+v, %v; want 3 reminders in backlog", st, err)
+	}
+}
+
+func TestSchedulerBadStateFile(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(state, []byte("{bad"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewScheduler(NewRepo(), &recorder{})
+	s.StateFile = state
+	if err := s.Run(context.Background()); err == nil {
+		t.Fatal("Run with a corrupt state file: want error")
+	}
+}
+
+type notifierFunc func(ctx context.Context, r Reminder) error
+
+func (f notifierFunc) Notify(ctx context.Context, r Reminder) error { return f(ctx, r) }
@@ -0,0 +1,89 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+// events — GET /api/tasks/events: поток изменений задач в формате
+// Server-Sent Events. Переподключившийся клиент (EventSource делает это сам)
+// присылает Last-Event-ID и получает пропущенные события из буфера брокера.
+// Если они уже вытеснены или id из прошлого запуска сервера, первым приходит событие reset — клиенту нужно
+// заново загрузить список через GET /api/tasks.
+func (h *Handler) events(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// поток живёт дольше WriteTimeout сервера — снимаем дедлайн для этого соединения
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		httpError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id") // для клиентов, которые не умеют ставить заголовок
+	}
+	sub, err := h.repo.Events().Subscribe(raw)
+	if err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	hdr := w.Header()
+	hdr.Set("Content-Type", "text/event-stream")
+	hdr.Set("Cache-Control", "no-cache")
+	hdr.Set("Connection", "keep-alive")
+	hdr.Set("X-Accel-Buffering", "no") // nginx не должен буферизовать поток
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if sub.Gap {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range sub.Backlog {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(heartbeatInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return // отстали или сервер останавливается — клиент переподключится
+			}
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		case <-ping.C:
+			// комментарий держит соединение открытым через прокси
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e Event) error {
+	data, err := json.Marshal(e.Task)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.LastEventID(), e.Type, data)
+	return err
+}
@@ -0,0 +1,114 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Node — задача с вложенными подзадачами для GET /api/tasks?tree=true.
+type Node struct {
+	*Task
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Children — прямые подзадачи id по возрастанию ID.
+func (r *Repo) Children(id int64) ([]*Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.items[id]; !ok {
+		return nil, ErrNotFound
+	}
+	out := make([]*Task, 0, len(r.ix.byParent[id]))
+	for cid := range r.ix.byParent[id] {
+		out = append(out, r.view(r.items[cid]))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// checkParent проверяет, что задачу id можно сделать подзадачей parent:
+// родитель существует и не лежит в поддереве самой задачи.
+// Для новой задачи id = 0. Вызывается под r.mu.
+func (r *Repo) checkParent(id, parent int64) error {
+	if parent == 0 {
+		return nil
+	}
+	if parent == id {
+		return ErrCycle
+	}
+	p, ok := r.items[parent]
+	if !ok {
+		return fmt.Errorf("%w: parent %d not found", ErrInvalid, parent)
+	}
+	// поднимаемся от нового родителя к корню: встретили id — получится цикл
+	for id != 0 && p != nil {
+		if p.ID == id {
+			return ErrCycle
+		}
+		p = r.items[p.ParentID]
+	}
+	return nil
+}
+
+// subtree — задача id и все её потомки; потомки идут раньше предков.
+func (r *Repo) subtree(id int64) []*Task {
+	var out []*Task
+	var walk func(id int64)
+	walk = func(id int64) {
+		for cid := range r.ix.byParent[id] {
+			walk(cid)
+		}
+		out = append(out, r.items[id])
+	}
+	walk(id)
+	return out
+}
+
+// view — копия задачи для отдачи наружу с посчитанным Progress.
+// Вызывается под r.mu.
+func (r *Repo) view(t *Task) *Task {
+	cp := clone(t)
+	if kids := r.ix.byParent[t.ID]; len(kids) > 0 {
+		done := 0
+		for cid := range kids {
+			if r.items[cid].Done {
+				done++
+			}
+		}
+		p := float64(done) / float64(len(kids))
+		cp.Progress = &p
+	}
+	return cp
+}
+
+// parentChanged вызывается, когда у родителя поменялся состав или статус
+// подзадач: его progress стал другим, поэтому растёт и Version (иначе ETag
+// родителя остался бы прежним при другом теле ответа) и рассылается updated.
+// Выше по дереву ничего не меняется: progress считается только по прямым
+// подзадачам, а Done самого родителя остаётся прежним.
+func (r *Repo) parentChanged(parent int64) {
+	if p, ok := r.items[parent]; ok {
+		p.Version++
+		r.events.Publish(EventUpdated, r.view(p))
+	}
+}
+
+// BuildTree раскладывает задачи по родителям, сохраняя их порядок.
+// Задача, родителя которой нет среди ts (например, он не прошёл фильтр),
+// становится корнем.
+func BuildTree(ts []*Task) []*Node {
+	nodes := make(map[int64]*Node, len(ts))
+	for _, t := range ts {
+		nodes[t.ID] = &Node{Task: t}
+	}
+	roots := make([]*Node, 0)
+	for _, t := range ts {
+		n := nodes[t.ID]
+		if p, ok := nodes[t.ParentID]; ok && t.ParentID != 0 {
+			p.Children = append(p.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	return roots
+}
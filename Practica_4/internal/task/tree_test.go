@@ -0,0 +1,129 @@
+package task
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+// progressOf — progress и версия задачи id; progress -1 — поля нет.
+func progressOf(t *testing.T, repo *Repo, id int64) (float64, int64) {
+	t.Helper()
+	task, err := repo.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Progress == nil {
+		return -1, task.Version
+	}
+	return *task.Progress, task.Version
+}
+
+func TestSubtaskProgressBumpsParentVersion(t *testing.T) {
+	repo := NewRepo()
+	mustCreate := func(f Fields) *Task {
+		t.Helper()
+		task, err := repo.Create(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	root := mustCreate(Fields{Title: "root"})
+	parent := mustCreate(Fields{Title: "parent", ParentID: root.ID})
+	a := mustCreate(Fields{Title: "a", ParentID: parent.ID})
+	b := mustCreate(Fields{Title: "b", ParentID: parent.ID})
+
+	check := func(step string, wantProgress float64, wantVersion int64) {
+		t.Helper()
+		p, v := progressOf(t, repo, parent.ID)
+		if p != wantProgress || v != wantVersion {
+			t.Fatalf("%s: parent progress %v v%d; want %v v%d", step, p, v, wantProgress, wantVersion)
+		}
+	}
+	// создание каждой подзадачи поднимает версию родителя
+	check("two children", 0, 3)
+
+	sub, err := repo.Events().Subscribe("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	if _, err := repo.Update(a.ID, Fields{Title: "a", ParentID: parent.ID, Done: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	check("a done", 0.5, 4)
+	// родитель получает updated с новым progress, ETag совпадает с версией
+	e1, e2 := <-sub.C, <-sub.C
+	if e1.Task.ID != a.ID || e2.Type != EventUpdated || e2.Task.ID != parent.ID ||
+		e2.Task.Progress == nil || *e2.Task.Progress != 0.5 || etag(e2.Task) != `"4"` {
+		t.Fatalf("events = %+v, %+v", e1, e2.Task)
+	}
+
+	// изменение без смены статуса progress не меняет — версия родителя та же
+	if _, err := repo.Update(a.ID, Fields{Title: "a2", ParentID: parent.ID, Done: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	check("rename", 0.5, 4)
+
+	// перенос подзадачи меняет progress у старого и нового родителя
+	if _, err := repo.Update(b.ID, Fields{Title: "b", ParentID: root.ID}, nil); err != nil {
+		t.Fatal(err)
+	}
+	check("b moved out", 1, 5)
+	if p, v := progressOf(t, repo, root.ID); p != 0 || v != 3 {
+		t.Fatalf("root after move: progress %v v%d; want 0 v3", p, v)
+	}
+
+	if err := repo.Delete(a.ID, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	check("last child deleted", -1, 6)
+
+	// выше по дереву ничего не меняется: progress считается только по прямым подзадачам
+	if p, v := progressOf(t, repo, root.ID); p != 0 || v != 3 {
+		t.Fatalf("root: progress %v v%d; want 0 v3", p, v)
+	}
+}
+
+func TestSubtaskTreeRules(t *testing.T) {
+	repo := NewRepo()
+	for _, f := range []Fields{{Title: "1"}, {Title: "2", ParentID: 1}, {Title: "3", ParentID: 2}} {
+		if _, err := repo.Create(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.Create(Fields{Title: "x", ParentID: 42}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("missing parent: %v; want ErrInvalid", err)
+	}
+	for _, parent := range []int64{1, 3} {
+		if _, err := repo.Update(1, Fields{Title: "1", ParentID: parent}, nil); !errors.Is(err, ErrCycle) {
+			t.Fatalf("move 1 under %d: %v; want ErrCycle", parent, err)
+		}
+	}
+	if err := repo.Delete(1, nil, false); !errors.Is(err, ErrHasChildren) {
+		t.Fatalf("delete with children: %v; want ErrHasChildren", err)
+	}
+	if kids, err := repo.Children(1); err != nil || len(kids) != 1 || kids[0].ID != 2 {
+		t.Fatalf("Children(1) = %v, %v", kids, err)
+	}
+
+	all := repo.List()
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	roots := BuildTree(all)
+	if len(roots) != 1 || len(roots[0].Children) != 1 || len(roots[0].Children[0].Children) != 1 {
+		t.Fatalf("tree = %+v", roots)
+	}
+	// без родителя в выборке подзадача становится корнем
+	if roots := BuildTree(all[1:]); len(roots) != 1 || roots[0].ID != 2 {
+		t.Fatalf("partial tree roots = %+v", roots)
+	}
+
+	if err := repo.Delete(1, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(repo.List()); n != 0 {
+		t.Fatalf("cascade delete left %d tasks", n)
+	}
+}
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
@@ -12,14 +19,46 @@ import (
 )
 
 func main() {
+	cors, err := myMW.CORS(corsPolicyFromEnv())
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	repo := task.NewRepo()
 	h := task.NewHandler(repo)
+	h.RequireIfMatch = os.Getenv("REQUIRE_IF_MATCH") == "true"
+	h.Timeout = 30 * time.Second
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// доставка напоминаний не блокирует тик планировщика
+	notifier := task.NewQueueNotifier(notifierFromEnv(), 100)
+	notifyDone := make(chan struct{})
+	var unsent []task.Reminder
+	go func() {
+		defer close(notifyDone)
+		unsent = notifier.Run(ctx)
+	}()
+
+	sched := task.NewScheduler(repo, notifier)
+	sched.StateFile = os.Getenv("SCHEDULER_STATE_FILE")
+	if v := os.Getenv("SCHEDULER_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("SCHEDULER_INTERVAL: invalid duration %q", v)
+		}
+		sched.Interval = d
+	}
+	// Run возвращает ошибку сразу (не прочитался StateFile) или nil после отмены ctx
+	schedErr := make(chan error, 1)
+	go func() { schedErr <- sched.Run(ctx) }()
 
 	r := chi.NewRouter()
 	r.Use(chimw.RequestID)
 	r.Use(chimw.Recoverer)
 	r.Use(myMW.Logger)
-	r.Use(myMW.SimpleCORS)
+	r.Use(cors)
 
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
@@ -29,7 +68,77 @@ func main() {
 		api.Mount("/tasks", h.Routes())
 	})
 
-	addr := ":8080"
-	log.Printf("listening on %s", addr)
-	log.Fatal(http.ListenAndServe(addr, r))
+	// WriteTimeout не обрывает SSE: обработчик /events снимает дедлайн сам
+	srv := &http.Server{
+		Addr:              ":8080",
+		Handler:           r,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      60 * time.Second,
+	}
+	// Shutdown не закрывает активные соединения — закрываем SSE-потоки сами
+	srv.RegisterOnShutdown(repo.Events().Close)
+	go func() {
+		log.Printf("listening on %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	// без планировщика напоминания молча перестали бы приходить — останавливаем сервер
+	failed := false
+	select {
+	case <-ctx.Done():
+	case err := <-schedErr:
+		log.Printf("scheduler: %v", err)
+		failed = true
+		stop()
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	if !failed {
+		<-schedErr
+	}
+	<-notifyDone
+	if !failed {
+		// неотправленные напоминания сохраняются в SCHEDULER_STATE_FILE и уйдут после рестарта
+		sched.Requeue(unsent)
+	}
+	log.Printf("stopped")
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// notifierFromEnv: REMINDER_WEBHOOK_URL — слать напоминания POST-запросом,
+// иначе они пишутся в лог.
+func notifierFromEnv() task.Notifier {
+	if url := os.Getenv("REMINDER_WEBHOOK_URL"); url != "" {
+		return task.NewWebhookNotifier(url)
+	}
+	return task.LogNotifier{}
+}
+
+// corsPolicyFromEnv: CORS_ALLOWED_ORIGINS — список через запятую
+// (по умолчанию "*"), CORS_ALLOW_CREDENTIALS=true — разрешить cookies.
+func corsPolicyFromEnv() myMW.CORSPolicy {
+	origins := []string{"*"}
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		origins = strings.Split(v, ",")
+	}
+	return myMW.CORSPolicy{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "HEAD"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"ETag"},
+		AllowCredentials: os.Getenv("CORS_ALLOW_CREDENTIALS") == "true",
+		MaxAge:           10 * time.Minute,
+		Routes: []myMW.CORSRoute{{
+			PathPrefix:     "/api/",
+			AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "DELETE"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "If-Match", "If-None-Match"},
+		}},
+	}
 }
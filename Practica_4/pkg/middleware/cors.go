@@ -1,16 +1,252 @@
 package middleware
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func SimpleCORS(next http.Handler) http.Handler {
+// CORSPolicy описывает, каким origin'ам и с какими методами/заголовками
+// разрешено обращаться к API из браузера.
+type CORSPolicy struct {
+	// AllowedOrigins: точные origin'ы ("https://app.example.com"),
+	// шаблоны поддоменов ("https://*.example.com" — любой поддомен, но не сам example.com)
+	// или "*" — любой origin (несовместимо с AllowCredentials).
+	AllowedOrigins []string
+	AllowedMethods []string // по умолчанию GET, HEAD, POST
+	AllowedHeaders []string // заголовки, которые клиент может прислать
+	ExposedHeaders []string // заголовки ответа, доступные JS (например, ETag)
+	// AllowCredentials разрешает cookies и Authorization; тогда в ответ
+	// всегда уходит конкретный origin, а не "*".
+	AllowCredentials bool
+	MaxAge           time.Duration // сколько браузер кеширует preflight
+	// Routes переопределяют методы и заголовки для путей с данным префиксом;
+	// выбирается самый длинный подходящий префикс.
+	Routes []CORSRoute
+}
+
+type CORSRoute struct {
+	PathPrefix     string
+	AllowedMethods []string
+	AllowedHeaders []string
+}
+
+// originPattern — "https://*.example.com[:port]"; suffix хранится как ".example.com".
+type originPattern struct {
+	scheme, suffix, port string
+}
+
+type corsRule struct {
+	prefix  string
+	methods map[string]bool
+	headers map[string]bool
+	allow   string // значение Access-Control-Allow-Methods
+}
+
+type cors struct {
+	any         bool
+	exact       map[string]bool
+	patterns    []originPattern
+	credentials bool
+	exposed     string
+	maxAge      string
+	rules       []corsRule // по убыванию длины префикса, последним — правило по умолчанию
+}
+
+// CORS собирает middleware по политике. Ошибка — если политика противоречива
+// (например, "*" вместе с AllowCredentials) или origin записан некорректно.
+func CORS(p CORSPolicy) (func(http.Handler) http.Handler, error) {
+	c := &cors{exact: make(map[string]bool), credentials: p.AllowCredentials}
+
+	for _, o := range p.AllowedOrigins {
+		o = strings.TrimSpace(o)
+		switch {
+		case o == "*":
+			c.any = true
+		case strings.Contains(o, "*"):
+			pat, err := parseOriginPattern(o)
+			if err != nil {
+				return nil, err
+			}
+			c.patterns = append(c.patterns, pat)
+		case o != "":
+			c.exact[strings.ToLower(o)] = true
+		}
+	}
+	if c.any && p.AllowCredentials {
+		return nil, errors.New("cors: AllowCredentials cannot be combined with origin \"*\"")
+	}
+
+	if len(p.ExposedHeaders) > 0 {
+		c.exposed = strings.Join(p.ExposedHeaders, ", ")
+	}
+	if p.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(p.MaxAge.Seconds()))
+	}
+
+	methods := p.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	for _, rt := range p.Routes {
+		m, h := rt.AllowedMethods, rt.AllowedHeaders
+		if len(m) == 0 {
+			m = methods
+		}
+		if len(h) == 0 {
+			h = p.AllowedHeaders
+		}
+		c.rules = append(c.rules, newRule(rt.PathPrefix, m, h))
+	}
+	sort.SliceStable(c.rules, func(i, j int) bool { return len(c.rules[i].prefix) > len(c.rules[j].prefix) })
+	c.rules = append(c.rules, newRule("", methods, p.AllowedHeaders))
+
+	return c.handler, nil
+}
+
+func newRule(prefix string, methods, headers []string) corsRule {
+	r := corsRule{prefix: prefix, methods: make(map[string]bool), headers: make(map[string]bool)}
+	list := make([]string, 0, len(methods))
+	for _, m := range methods {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		r.methods[m] = true
+		list = append(list, m)
+	}
+	r.allow = strings.Join(list, ", ")
+	for _, h := range headers {
+		r.headers[http.CanonicalHeaderKey(strings.TrimSpace(h))] = true
+	}
+	return r
+}
+
+func parseOriginPattern(o string) (originPattern, error) {
+	bad := errors.New("cors: invalid origin pattern " + strconv.Quote(o))
+	scheme, rest, ok := strings.Cut(strings.ToLower(o), "://")
+	if !ok || scheme == "" || !strings.HasPrefix(rest, "*.") {
+		return originPattern{}, bad
+	}
+	suffix, port := rest[1:], ""
+	if i := strings.LastIndex(suffix, ":"); i >= 0 {
+		suffix, port = suffix[:i], suffix[i+1:]
+	}
+	if len(suffix) < 2 || strings.ContainsAny(suffix[1:], "*/") {
+		return originPattern{}, bad
+	}
+	return originPattern{scheme: scheme, suffix: suffix, port: port}, nil
+}
+
+func (c *cors) handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
+		origin := r.Header.Get("Origin")
+		h := w.Header()
+		// ответ зависит от Origin — кеши не должны отдавать его другому origin'у.
+		// Это касается и запросов без Origin: иначе закешированный ответ без
+		// Access-Control-Allow-Origin достанется браузеру с разрешённым origin'ом.
+		// Только при политике "*" ответ на любой Origin одинаковый.
+		if !c.any || origin != "" {
+			h.Add("Vary", "Origin")
+		}
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !preflight {
+			if c.allowed(origin) {
+				c.setOrigin(h, origin)
+				if c.exposed != "" {
+					h.Set("Access-Control-Expose-Headers", c.exposed)
+				}
+			}
+			next.ServeHTTP(w, r)
 			return
 		}
-		next.ServeHTTP(w, r)
+
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		rule := c.rule(r.URL.Path)
+		method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+		reqHeaders, headersOK := rule.checkHeaders(r.Header.Values("Access-Control-Request-Headers"))
+		if !c.allowed(origin) || !rule.methods[method] || !headersOK {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		c.setOrigin(h, origin)
+		h.Set("Access-Control-Allow-Methods", rule.allow)
+		if reqHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", reqHeaders)
+		}
+		if c.maxAge != "" {
+			h.Set("Access-Control-Max-Age", c.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
 	})
 }
+
+func (c *cors) setOrigin(h http.Header, origin string) {
+	if c.any && !c.credentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *cors) allowed(origin string) bool {
+	if c.any {
+		return true
+	}
+	o := strings.ToLower(origin)
+	if c.exact[o] {
+		return true
+	}
+	if len(c.patterns) == 0 || o == "null" {
+		return false
+	}
+	u, err := url.Parse(o)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	for _, p := range c.patterns {
+		if u.Scheme == p.scheme && u.Port() == p.port && strings.HasSuffix(u.Hostname(), p.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) rule(path string) corsRule {
+	for _, r := range c.rules {
+		if strings.HasPrefix(path, r.prefix) {
+			return r
+		}
+	}
+	return c.rules[len(c.rules)-1]
+}
+
+// checkHeaders проверяет, что все запрошенные заголовки разрешены,
+// и возвращает их списком для Access-Control-Allow-Headers.
+func (r corsRule) checkHeaders(values []string) (string, bool) {
+	var out []string
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if !r.headers[name] {
+				return "", false
+			}
+			out = append(out, name)
+		}
+	}
+	return strings.Join(out, ", "), true
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func corsHandler(t *testing.T, p CORSPolicy) http.Handler {
+	t.Helper()
+	mw, err := CORS(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func serve(h http.Handler, method, path string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSInvalidPolicy(t *testing.T) {
+	for _, p := range []CORSPolicy{
+		{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		{AllowedOrigins: []string{"*.example.com"}},
+		{AllowedOrigins: []string{"https://app.*.example.com"}},
+		{AllowedOrigins: []string{"https://*."}},
+	} {
+		if _, err := CORS(p); err == nil {
+			t.Errorf("CORS(%v): want error", p.AllowedOrigins)
+		}
+	}
+}
+
+func TestCORSOriginMatching(t *testing.T) {
+	h := corsHandler(t, CORSPolicy{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org", "http://*.local:8080"},
+	})
+	tests := []struct {
+		origin string
+		allow  bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"http://app.example.com", false},
+		{"https://evil.example.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://a.example.org.evil.com", false},
+		{"https://a.example.org:8443", false},
+		{"http://dev.local:8080", true},
+		{"http://dev.local", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		rec := serve(h, http.MethodGet, "/", "Origin", tt.origin)
+		got := rec.Header().Get("Access-Control-Allow-Origin")
+		if tt.allow && got != tt.origin {
+			t.Errorf("%s: Allow-Origin = %q; want the origin echoed", tt.origin, got)
+		}
+		if !tt.allow && got != "" {
+			t.Errorf("%s: Allow-Origin = %q; want none", tt.origin, got)
+		}
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d; simple requests always reach the handler", tt.origin, rec.Code)
+		}
+	}
+}
+
+func TestCORSVary(t *testing.T) {
+	wildcard := CORSPolicy{AllowedOrigins: []string{"*"}}
+	exact := CORSPolicy{AllowedOrigins: []string{"https://app.example.com"}}
+	tests := []struct {
+		name   string
+		policy CORSPolicy
+		origin string
+		want   string
+	}{
+		{"wildcard without origin", wildcard, "", ""},
+		{"wildcard with origin", wildcard, "https://x.test", "Origin"},
+		{"exact without origin", exact, "", "Origin"},
+		{"exact allowed", exact, "https://app.example.com", "Origin"},
+		{"exact rejected", exact, "https://x.test", "Origin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(corsHandler(t, tt.policy), http.MethodGet, "/", "Origin", tt.origin)
+			if got := strings.Join(rec.Header().Values("Vary"), ", "); got != tt.want {
+				t.Fatalf("Vary = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSCredentialsAndExpose(t *testing.T) {
+	h := corsHandler(t, CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		ExposedHeaders:   []string{"ETag", "X-Total-Count"},
+	})
+	rec := serve(h, http.MethodGet, "/", "Origin", "https://app.example.com")
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("Allow-Credentials = %q; want true", got)
+	}
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "ETag, X-Total-Count" {
+		t.Fatalf("Expose-Headers = %q", got)
+	}
+
+	// при "*" без credentials уходит "*", а не origin
+	rec = serve(corsHandler(t, CORSPolicy{AllowedOrigins: []string{"*"}}), http.MethodGet, "/", "Origin", "https://x.test")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("wildcard Allow-Origin = %q; want *", got)
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	h := corsHandler(t, CORSPolicy{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type"},
+		MaxAge:         10 * time.Minute,
+		Routes: []CORSRoute{
+			{PathPrefix: "/api/tasks", AllowedMethods: []string{"GET", "PUT", "DELETE"}, AllowedHeaders: []string{"Content-Type", "If-Match"}},
+			{PathPrefix: "/api", AllowedMethods: []string{"GET"}},
+		},
+	})
+	const origin = "https://app.example.com"
+	tests := []struct {
+		name, path, origin, method, headers string
+		want                                int
+		wantMethods                         string
+	}{
+		{"default rule", "/health", origin, "POST", "content-type", http.StatusNoContent, "GET, POST"},
+		{"longest prefix", "/api/tasks/1", origin, "PUT", "Content-Type, If-Match", http.StatusNoContent, "GET, PUT, DELETE"},
+		{"shorter prefix", "/api/other", origin, "PUT", "", http.StatusForbidden, ""},
+		{"route inherits headers", "/api/other", origin, "GET", "Content-Type", http.StatusNoContent, "GET"},
+		{"header not allowed", "/api/other", origin, "GET", "If-Match", http.StatusForbidden, ""},
+		{"method not allowed", "/health", origin, "DELETE", "", http.StatusForbidden, ""},
+		{"origin not allowed", "/health", "https://x.test", "GET", "", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, http.MethodOptions, tt.path,
+				"Origin", tt.origin,
+				"Access-Control-Request-Method", tt.method,
+				"Access-Control-Request-Headers", tt.headers)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d; want %d", rec.Code, tt.want)
+			}
+			vary := strings.Join(rec.Header().Values("Vary"), ", ")
+			if vary != "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" {
+				t.Fatalf("Vary = %q", vary)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Fatalf("Allow-Methods = %q; want %q", got, tt.wantMethods)
+			}
+			if tt.want == http.StatusNoContent && rec.Header().Get("Access-Control-Max-Age") != "600" {
+				t.Fatalf("Max-Age = %q; want 600", rec.Header().Get("Access-Control-Max-Age"))
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 )
 
+// Logger пишет строку после ответа. ResponseWriter передаётся дальше как есть,
+// поэтому Flush для SSE-потоков работает; для потока строка появится при его закрытии.
 func Logger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// BulkOptions — настройки CreateManyWith.
+type BulkOptions struct {
+	// ChunkSize — строк в одной порции; по умолчанию 5000 для COPY и 1000 для INSERT
+	// (у INSERT ... VALUES предел PostgreSQL — 65535 параметров на запрос,
+	// по два на строку).
+	ChunkSize int
+	// Progress вызывается после каждой порции: сколько вставлено из скольких.
+	// Данные видны другим сессиям только после успешного завершения всей вставки.
+	Progress func(done, total int)
+	// NoCopy — не использовать COPY даже через pgx (для сравнения скорости).
+	NoCopy bool
+}
+
+const (
+	copyChunkSize   = 5000
+	insertChunkSize = 1000
+)
+
+// CreateManyWith вставляет задачи одной транзакцией и возвращает их ID
+// в порядке titles. Через драйвер pgx используется протокол COPY, иначе —
+// многострочный INSERT ... VALUES. Оба пути работают только с PostgreSQL
+// (ID выделяются через nextval), как и весь Repo. Пустое название
+// в titles — ErrEmptyTitle, до обращения к БД.
+func (r *Repo) CreateManyWith(ctx context.Context, titles []string, opt BulkOptions) ([]int, error) {
+	if len(titles) == 0 {
+		return nil, nil
+	}
+	titles, err := checkTitles(titles)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := r.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var ids []int
+	usedCopy := false
+	if !opt.NoCopy {
+		err = conn.Raw(func(driverConn any) error {
+			c, ok := driverConn.(*stdlib.Conn)
+			if !ok {
+				return nil // не pgx — ниже вставим через INSERT
+			}
+			usedCopy = true
+			var cerr error
+			ids, cerr = copyTasks(ctx, c.Conn(), titles, opt)
+			return cerr
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !usedCopy {
+		ids, err = insertTasks(ctx, conn, titles, opt)
+	}
+	return ids, err
+}
+
+// copyTasks: COPY не умеет RETURNING, поэтому ID заранее берутся из
+// последовательности tasks.id (nextval) и передаются явно — так они
+// гарантированно соответствуют порядку titles.
+func copyTasks(ctx context.Context, conn *pgx.Conn, titles []string, opt BulkOptions) ([]int, error) {
+	size := opt.ChunkSize
+	if size <= 0 {
+		size = copyChunkSize
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]int, 0, len(titles))
+	for start := 0; start < len(titles); start += size {
+		chunk := titles[start:min(start+size, len(titles))]
+
+		const q = `SELECT nextval(pg_get_serial_sequence('tasks', 'id')) FROM generate_series(1, $1)`
+		rows, err := tx.Query(ctx, q, len(chunk))
+		if err != nil {
+			return nil, err
+		}
+		chunkIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return nil, err
+		}
+		if len(chunkIDs) != len(chunk) {
+			return nil, fmt.Errorf("allocated %d ids for %d rows", len(chunkIDs), len(chunk))
+		}
+
+		src := pgx.CopyFromSlice(len(chunk), func(i int) ([]any, error) {
+			return []any{chunkIDs[i], chunk[i]}, nil
+		})
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"tasks"}, []string{"id", "title"}, src); err != nil {
+			return nil, err
+		}
+		ids = append(ids, chunkIDs...)
+		if opt.Progress != nil {
+			opt.Progress(len(ids), len(titles))
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// insertTasks — запасной путь через database/sql: порции по
+// INSERT INTO tasks (id, title) VALUES ($1, $2), ($3, $4), ...
+// Порядок строк RETURNING не гарантирован, поэтому ID, как и в copyTasks,
+// заранее берутся из последовательности и передаются явно. Запасной путь —
+// для другого драйвера PostgreSQL (например, lib/pq), а не для другой СУБД:
+// nextval и pg_get_serial_sequence есть только в PostgreSQL.
+func insertTasks(ctx context.Context, conn *sql.Conn, titles []string, opt BulkOptions) ([]int, error) {
+	size := opt.ChunkSize
+	if size <= 0 || size > insertChunkSize {
+		size = insertChunkSize
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, 0, len(titles))
+	var b strings.Builder
+	args := make([]any, 0, 2*size)
+	for start := 0; start < len(titles); start += size {
+		chunk := titles[start:min(start+size, len(titles))]
+
+		const q = `SELECT nextval(pg_get_serial_sequence('tasks', 'id')) FROM generate_series(1, $1)`
+		rows, err := tx.QueryContext(ctx, q, len(chunk))
+		if err != nil {
+			return nil, err
+		}
+		chunkIDs := make([]int, 0, len(chunk))
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			chunkIDs = append(chunkIDs, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if len(chunkIDs) != len(chunk) {
+			return nil, fmt.Errorf("allocated %d ids for %d rows", len(chunkIDs), len(chunk))
+		}
+
+		b.Reset()
+		args = args[:0]
+		b.WriteString(`INSERT INTO tasks (id, title) VALUES `)
+		for i, title := range chunk {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "($%d, $%d)", 2*i+1, 2*i+2)
+			args = append(args, chunkIDs[i], title)
+		}
+		if _, err := tx.ExecContext(ctx, b.String(), args...); err != nil {
+			return nil, err
+		}
+		ids = append(ids, chunkIDs...)
+		if opt.Progress != nil {
+			opt.Progress(len(ids), len(titles))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePG — драйвер database/sql, который отвечает на запросы запасного пути
+// insertTasks как PostgreSQL: выдаёт ID из «последовательности» и запоминает
+// INSERT-ы. Так путь без COPY проверяется без живой базы.
+type fakePG struct {
+	mu      sync.Mutex
+	seq     int64
+	inserts [][]driver.Value // аргументы каждого INSERT
+	log     []string         // begin, nextval, insert, commit, rollback
+	failAt  int              // номер INSERT (с 1), который вернёт ошибку; 0 — никакой
+}
+
+var fakePGDriver = &fakePG{}
+
+func init() { sql.Register("fakepg", fakePGDriver) }
+
+func (d *fakePG) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+func (d *fakePG) record(s string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = append(d.log, s)
+}
+
+type fakeConn struct{ d *fakePG }
+
+func (c fakeConn) Prepare(q string) (driver.Stmt, error) { return fakeStmt{c.d, q}, nil }
+func (c fakeConn) Close() error                          { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.d.record("begin")
+	return fakeTx{c.d}, nil
+}
+
+type fakeTx struct{ d *fakePG }
+
+func (t fakeTx) Commit() error   { t.d.record("commit"); return nil }
+func (t fakeTx) Rollback() error { t.d.record("rollback"); return nil }
+
+type fakeStmt struct {
+	d *fakePG
+	q string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(s.q, "INSERT INTO tasks (id, title) VALUES ") {
+		return nil, errors.New("fakepg: unexpected exec: " + s.q)
+	}
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.log = append(s.d.log, "insert")
+	s.d.inserts = append(s.d.inserts, args)
+	if len(s.d.inserts) == s.d.failAt {
+		return nil, errors.New("fakepg: insert rejected")
+	}
+	return driver.RowsAffected(len(args) / 2), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.Contains(s.q, "nextval(pg_get_serial_sequence('tasks', 'id'))") {
+		return nil, errors.New("fakepg: unexpected query: " + s.q)
+	}
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.log = append(s.d.log, "nextval")
+	ids := make([]int64, args[0].(int64))
+	for i := range ids {
+		s.d.seq++
+		ids[i] = s.d.seq
+	}
+	return &fakeRows{ids: ids}, nil
+}
+
+type fakeRows struct{ ids []int64 }
+
+func (r *fakeRows) Columns() []string { return []string{"nextval"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.ids) == 0 {
+		return io.EOF
+	}
+	dest[0], r.ids = r.ids[0], r.ids[1:]
+	return nil
+}
+
+// openFakePG сбрасывает состояние драйвера; тесты с ним не параллельны.
+func openFakePG(t *testing.T, failAt int) *sql.DB {
+	t.Helper()
+	fakePGDriver.mu.Lock()
+	fakePGDriver.seq, fakePGDriver.inserts, fakePGDriver.log, fakePGDriver.failAt = 100, nil, nil, failAt
+	fakePGDriver.mu.Unlock()
+	db, err := sql.Open("fakepg", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// Драйвер не pgx — CreateManyWith идёт через INSERT с заранее выделенными ID.
+func TestCreateManyWithInsertFallback(t *testing.T) {
+	r := NewRepo(openFakePG(t, 0))
+	var progress []int
+	ids, err := r.CreateManyWith(context.Background(), []string{"a", " b ", "c", "d", "e"}, BulkOptions{
+		ChunkSize: 2,
+		Progress:  func(done, total int) { progress = append(progress, done) },
+	})
+	if err != nil {
+		t.Fatalf("CreateManyWith: %v", err)
+	}
+	if !equalIDs(ids, []int{101, 102, 103, 104, 105}) {
+		t.Fatalf("ids = %v; want 101..105 in titles order", ids)
+	}
+	if !equalIDs(progress, []int{2, 4, 5}) {
+		t.Fatalf("progress = %v; want [2 4 5]", progress)
+	}
+	want := "begin nextval insert nextval insert nextval insert commit"
+	if got := strings.Join(fakePGDriver.log, " "); got != want {
+		t.Fatalf("statements = %q; want %q", got, want)
+	}
+	// каждая строка вставляется со своим ID, названия — уже очищенные
+	var got []string
+	for _, args := range fakePGDriver.inserts {
+		for i := 0; i < len(args); i += 2 {
+			got = append(got, fmt.Sprintf("%v=%v", args[i], args[i+1]))
+		}
+	}
+	wantRows := "101=a 102=b 103=c 104=d 105=e"
+	if strings.Join(got, " ") != wantRows {
+		t.Fatalf("inserted rows = %v; want %s", got, wantRows)
+	}
+}
+
+func TestCreateManyWithInsertFallbackRollback(t *testing.T) {
+	r := NewRepo(openFakePG(t, 2))
+	_, err := r.CreateManyWith(context.Background(), []string{"a", "b", "c"}, BulkOptions{ChunkSize: 2})
+	if err == nil {
+		t.Fatal("CreateManyWith: want error from the second chunk")
+	}
+	log := fakePGDriver.log
+	if log[len(log)-1] != "rollback" || strings.Contains(strings.Join(log, " "), "commit") {
+		t.Fatalf("statements = %v; want rollback and no commit", log)
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var commands = map[string]func(c *cli, args []string) error{
+	"add":     (*cli).add,
+	"list":    (*cli).list,
+	"show":    (*cli).show,
+	"done":    (*cli).done,
+	"rm":      (*cli).rm,
+	"import":  (*cli).importFile,
+	"migrate": (*cli).migrate,
+	"pool":    (*cli).poolStats,
+	"load":    (*cli).load,
+}
+
+func (c *cli) add(args []string) error {
+	title := strings.TrimSpace(strings.Join(args, " "))
+	if title == "" {
+		return fmt.Errorf("%w: add TITLE", errUsage)
+	}
+	var id int
+	err := c.call(func(ctx context.Context) (err error) {
+		id, err = c.repo.CreateTask(ctx, title)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out, id)
+	return nil
+}
+
+// list читает таблицу страницами через ListPage (с --done — ListDonePage,
+// фильтр в SQL), а не целиком; каждая страница — отдельное обращение со своим таймаутом.
+func (c *cli) list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(c.errOut)
+	onlyDone := fs.Bool("done", false, "только выполненные")
+	asJSON := fs.Bool("json", false, "вывод в JSON")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return fmt.Errorf("%w: list [--done] [--json]", errUsage)
+	}
+
+	enc := json.NewEncoder(c.out)
+	if *asJSON {
+		// массив закрывается и при ошибке посреди вывода: stdout остаётся
+		// корректным JSON, а о неполном списке сообщают stderr и код выхода
+		fmt.Fprint(c.out, "[")
+		defer fmt.Fprintln(c.out, "]")
+	}
+	n := 0
+	for after := 0; ; {
+		var page []Task
+		err := c.call(func(ctx context.Context) (err error) {
+			if *onlyDone {
+				page, err = c.repo.ListDonePage(ctx, true, after, maxPageSize)
+			} else {
+				page, err = c.repo.ListPage(ctx, after, maxPageSize)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			break
+		}
+		after = page[len(page)-1].ID
+		for _, t := range page {
+			if *asJSON {
+				if n > 0 {
+					fmt.Fprint(c.out, ",")
+				}
+				if err := enc.Encode(t); err != nil {
+					return err
+				}
+			} else {
+				printTask(c.out, t)
+			}
+			n++
+		}
+	}
+	return nil
+}
+
+func (c *cli) show(args []string) error {
+	id, err := parseID("show", args)
+	if err != nil {
+		return err
+	}
+	var t *Task
+	err = c.call(func(ctx context.Context) (err error) {
+		t, err = c.repo.FindByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	printTask(c.out, *t)
+	return nil
+}
+
+func (c *cli) done(args []string) error {
+	id, err := parseID("done", args)
+	if err != nil {
+		return err
+	}
+	return c.call(func(ctx context.Context) error { return c.repo.MarkDone(ctx, id) })
+}
+
+func (c *cli) rm(args []string) error {
+	id, err := parseID("rm", args)
+	if err != nil {
+		return err
+	}
+	return c.call(func(ctx context.Context) error { return c.repo.Delete(ctx, id) })
+}
+
+// importFile: одна непустая строка — одна задача. Вставка одной транзакцией
+// через CreateManyWith. Таймаут — на каждую порцию, а не на всю вставку:
+// сотня тысяч строк пишется дольше -timeout, поэтому отсчёт начинается
+// заново после каждой записанной порции.
+func (c *cli) importFile(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: import FILE", errUsage)
+	}
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var titles []string
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if t := strings.TrimSpace(sc.Text()); t != "" {
+			titles = append(titles, t)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(titles) == 0 {
+		fmt.Fprintln(c.errOut, "import: файл пуст")
+		return nil
+	}
+
+	if err := c.connect(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	stall := time.AfterFunc(c.timeout, func() { cancel(context.DeadlineExceeded) })
+	defer stall.Stop()
+
+	start := time.Now()
+	ids, err := c.repo.CreateManyWith(ctx, titles, BulkOptions{
+		Progress: func(done, total int) {
+			stall.Reset(c.timeout)
+			fmt.Fprintf(c.errOut, "import: %d/%d\n", done, total)
+		},
+	})
+	if errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		return fmt.Errorf("no chunk written within %s: %w", c.timeout, context.DeadlineExceeded)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "imported %d tasks (id %d..%d) in %s\n",
+		len(ids), ids[0], ids[len(ids)-1], time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// migrate [up | down N | status]
+func (c *cli) migrate(args []string) error {
+	cmd, n := "up", 1
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+	switch {
+	case cmd == "down" && len(args) == 2:
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 1 {
+			return fmt.Errorf("%w: migrate down N", errUsage)
+		}
+		n = v
+	case cmd != "up" && cmd != "down" && cmd != "status", len(args) > 2, len(args) == 2 && cmd != "down":
+		return fmt.Errorf("%w: migrate [up | down N | status]", errUsage)
+	}
+
+	if err := c.connect(); err != nil {
+		return err
+	}
+	m, err := NewMigrator(c.repo.DB)
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "up":
+		var done []int
+		err := c.call(func(ctx context.Context) (err error) {
+			done, err = m.Up(ctx)
+			return err
+		})
+		for _, v := range done {
+			fmt.Fprintf(c.out, "migration %04d applied\n", v)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Fprintln(c.out, "schema is up to date")
+		}
+		return err
+	case "down":
+		var done []int
+		err := c.call(func(ctx context.Context) (err error) {
+			done, err = m.Down(ctx, n)
+			return err
+		})
+		for _, v := range done {
+			fmt.Fprintf(c.out, "migration %04d rolled back\n", v)
+		}
+		return err
+	default: // status
+		var st []MigrationStatus
+		err := c.call(func(ctx context.Context) (err error) {
+			st, err = m.Status(ctx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for _, s := range st {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Modified {
+				state += " (MODIFIED)"
+			}
+			fmt.Fprintf(c.out, "%04d_%-24s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	}
+}
+
+func parseID(cmd string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%w: %s ID", errUsage, cmd)
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: invalid ID %q", errUsage, args[0])
+	}
+	return id, nil
+}
+
+func printTask(w io.Writer, t Task) {
+	fmt.Fprintf(w, "#%d | %-24s | done=%-5v | %s\n",
+		t.ID, t.Title, t.Done, t.CreatedAt.Format(time.RFC3339))
+}
+
+func (c *cli) poolStats(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("%w: pool", errUsage)
+	}
+	if err := c.connect(); err != nil {
+		return err
+	}
+	printPoolStats(c.out, c.pool, c.repo.DB.Stats())
+	return nil
+}
+
+// load гоняет нагрузку через Repo и печатает перцентили задержек рядом
+// со статистикой пула за время прогона — по ней подбираются DB_MAX_*.
+func (c *cli) load(args []string) error {
+	fs := flag.NewFlagSet("load", flag.ContinueOnError)
+	fs.SetOutput(c.errOut)
+	workers := fs.Int("c", 20, "одновременных горутин")
+	ops := fs.Int("n", 2000, "всего операций")
+	writes := fs.Float64("writes", 0, "доля операций записи, 0..1")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || *workers < 1 || *ops < 1 || *writes < 0 || *writes > 1 {
+		return fmt.Errorf("%w: load [-c N] [-n N] [-writes 0..1]", errUsage)
+	}
+	if err := c.connect(); err != nil {
+		return err
+	}
+	db := c.repo.DB
+
+	// Ctrl+C останавливает прогон, но результаты по уже выполненным операциям печатаются
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	before := db.Stats()
+	sampler := startPoolSampler(db, 20*time.Millisecond)
+	res, err := RunLoad(ctx, c.repo, LoadOptions{Workers: *workers, Ops: *ops, WriteRatio: *writes, OpTimeout: c.timeout})
+	peakOpen, peakInUse, peakWaits := sampler.Stop()
+	if res == nil {
+		return err
+	}
+	delta := statsDelta(before, db.Stats())
+
+	fmt.Fprintf(c.out, "load: %d workers, %d ops, writes=%.0f%%\n\n", *workers, *ops, *writes*100)
+	res.Print(c.out)
+	fmt.Fprintln(c.out)
+	printPoolStats(c.out, c.pool, delta)
+	fmt.Fprintf(c.out, "peak:          open=%d in_use=%d waits/20ms=%d\n", peakOpen, peakInUse, peakWaits)
+
+	if delta.WaitCount > 0 {
+		fmt.Fprintf(c.out, "\nhint: %d запросов ждали свободное соединение (в сумме %s) — пул упирается в max_open;\n"+
+			"      увеличьте DB_MAX_OPEN_CONNS, если БД справляется, или уменьшите -c\n",
+			delta.WaitCount, delta.WaitDuration.Round(time.Millisecond))
+	}
+	// Любое закрытие сверх max_idle под постоянной нагрузкой — лишнее переподключение
+	if delta.MaxIdleClosed > 0 {
+		fmt.Fprintf(c.out, "hint: %d соединений закрыто сверх max_idle (на %d операций) и открыто заново — увеличьте DB_MAX_IDLE_CONNS\n",
+			delta.MaxIdleClosed, *ops)
+	}
+	return err
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Общий контракт TaskRepository: каждая реализация прогоняет один и тот же
+// набор проверок на пустом хранилище.
+
+// failInsert заставляет хранилище r отклонять вставку задачи с названием title
+// уже при записи (триггером в БД), а не при проверке аргументов. nil — так
+// сломать вставку нельзя: MemRepo проверяет всё до первой записи.
+type failInsert func(t *testing.T, r TaskRepository, title string)
+
+func TestMemRepo(t *testing.T) {
+	testTaskRepository(t, func(t *testing.T) TaskRepository { return NewMemRepo() }, nil)
+}
+
+func TestSQLiteRepo(t *testing.T) {
+	testTaskRepository(t, func(t *testing.T) TaskRepository {
+		r, err := OpenSQLite(context.Background(), ":memory:")
+		if err != nil {
+			t.Fatalf("OpenSQLite: %v", err)
+		}
+		t.Cleanup(func() { r.Close() })
+		return r
+	}, func(t *testing.T, r TaskRepository, title string) {
+		q := `CREATE TRIGGER fail_insert BEFORE INSERT ON tasks WHEN NEW.title = ` + quote(title) + `
+			BEGIN SELECT RAISE(ABORT, 'insert rejected by test trigger'); END`
+		if _, err := r.(*SQLiteRepo).DB.Exec(q); err != nil {
+			t.Fatalf("create trigger: %v", err)
+		}
+	})
+}
+
+// TestPostgresRepo запускается, только если задан TEST_DATABASE_URL;
+// таблица tasks в этой базе очищается перед каждой проверкой.
+func TestPostgresRepo(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL не задан")
+	}
+	db, err := openDB(dsn, defaultPoolConfig())
+	if err != nil {
+		t.Fatalf("openDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	testTaskRepository(t, func(t *testing.T) TaskRepository {
+		if _, err := db.Exec(`TRUNCATE tasks RESTART IDENTITY`); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return NewRepo(db)
+	}, func(t *testing.T, r TaskRepository, title string) {
+		q := `CREATE OR REPLACE FUNCTION test_fail_insert() RETURNS trigger LANGUAGE plpgsql AS $$
+			BEGIN
+				IF NEW.title = ` + quote(title) + ` THEN
+					RAISE EXCEPTION 'insert rejected by test trigger';
+				END IF;
+				RETURN NEW;
+			END $$;
+			CREATE TRIGGER test_fail_insert BEFORE INSERT ON tasks
+				FOR EACH ROW EXECUTE FUNCTION test_fail_insert();`
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("create trigger: %v", err)
+		}
+		t.Cleanup(func() {
+			db.Exec(`DROP TRIGGER IF EXISTS test_fail_insert ON tasks; DROP FUNCTION IF EXISTS test_fail_insert()`)
+		})
+	})
+}
+
+// quote — строковый литерал SQL для названий из тестов
+func quote(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+
+func testTaskRepository(t *testing.T, newRepo func(t *testing.T) TaskRepository, fail failInsert) {
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, r TaskRepository)
+	}{
+		{"create_and_find", contractCreateAndFind},
+		{"ordering", contractOrdering},
+		{"done_filter", contractDoneFilter},
+		{"pagination", contractPagination},
+		{"done_pagination", contractDonePagination},
+		{"create_many", contractCreateMany},
+		{"create_many_all_or_nothing", contractCreateManyAtomic},
+		{"create_many_rollback", func(t *testing.T, r TaskRepository) { contractCreateManyRollback(t, r, fail) }},
+		{"not_found", contractNotFound},
+		{"empty_title", contractEmptyTitle},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) { c.fn(t, newRepo(t)) })
+	}
+}
+
+func mustCreate(t *testing.T, r TaskRepository, titles ...string) []int {
+	t.Helper()
+	ids := make([]int, len(titles))
+	for i, title := range titles {
+		id, err := r.CreateTask(context.Background(), title)
+		if err != nil {
+			t.Fatalf("CreateTask(%q): %v", title, err)
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+func taskIDs(tasks []Task) []int {
+	ids := make([]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func contractCreateAndFind(t *testing.T, r TaskRepository) {
+	ctx := context.Background()
+	id := mustCreate(t, r, "  купить хлеб ")[0]
+	got, err := r.FindByID(ctx, id)
+	if err != nil {
+		t.Fatalf("FindByID(%d): %v", id, err)
+	}
+	if got.ID != id || got.Title != "купить хлеб" || got.Done || got.CreatedAt.IsZero() {
+		t.Fatalf("FindByID(%d) = %+v; want title trimmed, done=false, created_at set", id, *got)
+	}
+
+	if err := r.UpdateTitle(ctx, id, "купить молоко"); err != nil {
+		t.Fatalf("UpdateTitle: %v", err)
+	}
+	for range 2 { // повторный MarkDone — не ошибка
+		if err := r.MarkDone(ctx, id); err != nil {
+			t.Fatalf("MarkDone: %v", err)
+		}
+	}
+	got, _ = r.FindByID(ctx, id)
+	if got.Title != "купить молоко" || !got.Done {
+		t.Fatalf("after update: %+v", *got)
+	}
+}
+
+func contractOrdering(t *testing.T, r TaskRepository) {
+	ctx := context.Background()
+	ids := mustCreate(t, r, "a", "b", "c", "d")
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids not increasing: %v", ids)
+		}
+	}
+	if err := r.Delete(ctx, ids[1]); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	e := mustCreate(t, r, "e")[0]
+	if e <= ids[3] {
+		t.Fatalf("new id %d reuses or precedes existing ids %v", e, ids)
+	}
+
+	list, err := r.ListTasks(ctx)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if want := []int{ids[0], ids[2], ids[3], e}; !equalIDs(taskIDs(list), want) {
+		t.Fatalf("ListTasks ids = %v; want %v", taskIDs(list), want)
+	}
+}
+
+func contractDoneFilter(t *testing.T, r TaskRepository) {
+	ctx := context.Background()
+	ids := mustCreate(t, r, "a", "b", "c", "d")
+	for _, id := range []int{ids[3], ids[1]} {
+		if err := r.MarkDone(ctx, id); err != nil {
+			t.Fatalf("MarkDone(%d): %v", id, err)
+		}
+	}
+	cases := []struct {
+		done bool
+		want []int
+	}{
+		{true, []int{ids[1], ids[3]}},
+		{false, []int{ids[0], ids[2]}},
+	}
+	for _, c := range cases {
+		got, err := r.ListDone(ctx, c.done)
+		if err != nil {
+			t.Fatalf("ListDone(%v): %v", c.done, err)
+		}
+		if !equalIDs(taskIDs(got), c.want) {
+			t.Fatalf("ListDone(%v) ids = %v; want %v", c.done, taskIDs(got), c.want)
+		}
+		for _, task := range got {
+			if task.Done != c.done {
+				t.Fatalf("ListDone(%v) returned %+v", c.done, task)
+			}
+		}
+	}
+}
+
+func contractPagination(t *testing.T, r TaskRepository) {
+	ctx := context.Background()
+	titles := make([]string, 7)
+	for i := range titles {
+		titles[i] = "task"
+	}
+	ids := mustCreate(t, r, titles...)
+
+	var got []int
+	for after := 0; ; {
+		page, err := r.ListPage(ctx, after, 3)
+		if err != nil {
+			t.Fatalf("ListPage(%d, 3): %v", after, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		if len(page) > 3 {
+			t.Fatalf("ListPage(%d, 3) returned %d tasks", after, len(page))
+		}
+		got = append(got, taskIDs(page)...)
+		after = page[len(page)-1].ID
+	}
+	if !equalIDs(got, ids) {
+		t.Fatalf("paged ids = %v; want %v", got, ids)
+	}
+
+	// limit <= 0 — размер по умолчанию, а не пустая страница
+	page, err := r.ListPage(ctx, 0, 0)
+	if err != nil || len(page) != len(ids) {
+		t.Fatalf("ListPage(0, 0) = %d tasks, %v; want %d", len(page), err, len(ids))
+	}
+}
+
+func contractDonePagination(t *testing.T, r TaskRepository) {
+	ctx := context.Background()
+	titles := make([]string, 9)
+	for i := range titles {
+		titles[i] = "task"
+	}
+	ids := mustCreate(t, r, titles...)
+	var want []int
+	for i, id := range ids {
+		if i%3 != 0 {
+			continue
+		}
+		if err := r.MarkDone(ctx, id); err != nil {
+			t.Fatalf("MarkDone(%d): %v", id, err)
+		}
+		want = append(want, id)
+	}
+
+	var got []int
+	for after := 0; ; {
+		page, err := r.ListDonePage(ctx, true, after, 2)
+		if err != nil {
+			t.Fatalf("ListDonePage(true, %d, 2): %v", after, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		// страница заполняется подходящими задачами, а не урезается фильтром
+		if len(page) != 2 && len(got)+len(page) != len(want) {
+			t.Fatalf("ListDonePage(true, %d, 2) returned %d tasks", after, len(page))
+		}
+		for _, task := range page {
+			if !task.Done {
+				t.Fatalf("ListDonePage(true) returned %+v", task)
+			}
+		}
+		got = append(got, taskIDs(page)...)
+		after = page[len(page)-1].ID
+	}
+	if !equalIDs(got, want) {
+		t.Fatalf("paged done ids = %v; want %v", got, want)
+	}
+
+	page, err := r.ListDonePage(ctx, false, 0, 0)
+	if err != nil || len(page) != len(ids)-len(want) {
+		t.Fatalf("ListDonePage(false, 0, 0) = %d tasks, %v; want %d", len(page), err, len(ids)-len(want))
+	}
+}
+
+func contractCreateMany(t *testing.T, r TaskRepository) {
+	ctx := context.Background()
+	titles := []string{"one", "two", "three"}
+	ids, err := r.CreateMany(ctx, titles)
+	if err != nil {
+		t.Fatalf("CreateMany: %v", err)
+	}
+	if len(ids) != len(titles) {
+		t.Fatalf("CreateMany returned %d ids; want %d", len(ids), len(titles))
+	}
+	for i, id := range ids {
+		task, err := r.FindByID(ctx, id)
+		if err != nil {
+			t.Fatalf("FindByID(%d): %v", id, err)
+		}
+		if task.Title != titles[i] {
+			t.Fatalf("ids[%d] = %d has title %q; want %q", i, id, task.Title, titles[i])
+		}
+	}
+
+	ids, err = r.CreateMany(ctx, nil)
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("CreateMany(nil) = %v, %v; want no ids, no error", ids, err)
+	}
+}
+
+func contractCreateManyAtomic(t *testing.T, r TaskRepository) {
+	ctx := context.Background()
+	mustCreate(t, r, "existing")
+
+	_, err := r.CreateMany(ctx, []string{"ok", "also ok", "  ", "never"})
+	if !errors.Is(err, ErrEmptyTitle) {
+		t.Fatalf("CreateMany with blank title: err = %v; want ErrEmptyTitle", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := r.CreateMany(cancelled, []string{"x", "y"}); err == nil {
+		t.Fatalf("CreateMany with cancelled context: want error")
+	}
+
+	list, err := r.ListTasks(ctx)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if len(list) != 1 || list[0].Title != "existing" {
+		t.Fatalf("after failed CreateMany: %+v; want only the existing task", list)
+	}
+}
+
+// contractCreateManyRollback: вставка падает в хранилище, когда часть строк
+// пачки уже записана, — записанные строки должны откатиться.
+func contractCreateManyRollback(t *testing.T, r TaskRepository, fail failInsert) {
+	if fail == nil {
+		t.Skip("хранилище не может отказать посреди вставки")
+	}
+	ctx := context.Background()
+	mustCreate(t, r, "existing")
+	fail(t, r, "boom")
+
+	if _, err := r.CreateMany(ctx, []string{"first", "second", "third", "boom"}); err == nil {
+		t.Fatalf("CreateMany with rejected row: want error")
+	}
+	list, err := r.ListTasks(ctx)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if len(list) != 1 || list[0].Title != "existing" {
+		t.Fatalf("after rolled back CreateMany: %+v; want only the existing task", list)
+	}
+
+	// хранилище осталось рабочим: следующая пачка без отклоняемой строки проходит
+	if _, err := r.CreateMany(ctx, []string{"a", "b"}); err != nil {
+		t.Fatalf("CreateMany after rollback: %v", err)
+	}
+}
+
+func contractNotFound(t *testing.T, r TaskRepository) {
+	ctx := context.Background()
+	id := mustCreate(t, r, "gone")[0]
+	if err := r.Delete(ctx, id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	ops := map[string]func() error{
+		"FindByID":    func() error { _, err := r.FindByID(ctx, id); return err },
+		"MarkDone":    func() error { return r.MarkDone(ctx, id) },
+		"UpdateTitle": func() error { return r.UpdateTitle(ctx, id, "new") },
+		"Delete":      func() error { return r.Delete(ctx, id) },
+	}
+	for name, op := range ops {
+		if err := op(); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s(deleted id): err = %v; want ErrNotFound", name, err)
+		}
+	}
+}
+
+func contractEmptyTitle(t *testing.T, r TaskRepository) {
+	ctx := context.Background()
+	if _, err := r.CreateTask(ctx, " "); !errors.Is(err, ErrEmptyTitle) {
+		t.Fatalf("CreateTask(blank): err = %v; want ErrEmptyTitle", err)
+	}
+	id := mustCreate(t, r, "title")[0]
+	if err := r.UpdateTitle(ctx, id, ""); !errors.Is(err, ErrEmptyTitle) {
+		t.Fatalf("UpdateTitle(blank): err = %v; want ErrEmptyTitle", err)
+	}
+	list, _ := r.ListTasks(ctx)
+	if len(list) != 1 || list[0].Title != "title" {
+		t.Fatalf("after rejected writes: %+v", list)
+	}
+}
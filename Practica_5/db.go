@@ -1,28 +1,75 @@
-package Practica_5
+package main
 
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
-func openDB(dsn string) (*sql.DB, error) {
+// PoolConfig — настройки пула database/sql. По умолчанию — значения,
+// которых достаточно для локалки; подбирать их лучше по `load` (см. load.go).
+type PoolConfig struct {
+	MaxOpenConns    int           // DB_MAX_OPEN_CONNS, 0 — без ограничения
+	MaxIdleConns    int           // DB_MAX_IDLE_CONNS
+	ConnMaxLifetime time.Duration // DB_CONN_MAX_LIFETIME, 0 — бессрочно
+	ConnMaxIdleTime time.Duration // DB_CONN_MAX_IDLE_TIME, 0 — бессрочно
+}
+
+func defaultPoolConfig() PoolConfig {
+	return PoolConfig{MaxOpenConns: 10, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute}
+}
+
+// PoolConfigFromEnv — defaultPoolConfig с переопределениями из окружения.
+func PoolConfigFromEnv() (PoolConfig, error) {
+	cfg := defaultPoolConfig()
+	for name, dst := range map[string]*int{
+		"DB_MAX_OPEN_CONNS": &cfg.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS": &cfg.MaxIdleConns,
+	} {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return cfg, fmt.Errorf("%s: want a non-negative integer, got %q", name, v)
+			}
+			*dst = n
+		}
+	}
+	for name, dst := range map[string]*time.Duration{
+		"DB_CONN_MAX_LIFETIME":  &cfg.ConnMaxLifetime,
+		"DB_CONN_MAX_IDLE_TIME": &cfg.ConnMaxIdleTime,
+	} {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return cfg, fmt.Errorf("%s: want a duration like 30m, got %q", name, v)
+			}
+			*dst = d
+		}
+	}
+	return cfg, nil
+}
+
+func openDB(dsn string, cfg PoolConfig) (*sql.DB, error) {
 	db, err := sql.Open("pgx", dsn)
 	if err != nil {
 		return nil, err
 	}
-	// настройки пула — достаточно для локалки
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(30 * time.Minute)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	// проверка соединения с таймаутом
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 	if err := db.PingContext(ctx); err != nil {
+		db.Close()
 		return nil, err
 	}
 	log.Println("Connected to PostgreSQL")
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoadOptions — параметры нагрузочного прогона через TaskRepository.
+type LoadOptions struct {
+	Workers    int           // одновременных горутин
+	Ops        int           // всего операций
+	WriteRatio float64       // доля записей (CreateTask + Delete), остальное — чтение пополам find/page
+	OpTimeout  time.Duration // таймаут одной операции
+}
+
+type opKind int
+
+const (
+	opFind  opKind = iota // FindByID случайной задачи
+	opPage                // ListPage(случайный id, 50)
+	opWrite               // CreateTask и сразу Delete — таблица не растёт
+	opKinds
+)
+
+var opNames = [opKinds]string{"find", "page", "write"}
+
+// LoadResult — задержки по видам операций.
+type LoadResult struct {
+	Elapsed time.Duration
+	lat     [opKinds][]time.Duration
+	errs    [opKinds]int
+	lastErr error
+}
+
+type workerResult struct {
+	lat     [opKinds][]time.Duration
+	errs    [opKinds]int
+	lastErr error
+}
+
+// RunLoad выполняет opt.Ops операций в opt.Workers горутинах. Для чтения
+// заранее берётся выборка существующих ID (первые 1000 задач).
+func RunLoad(ctx context.Context, repo TaskRepository, opt LoadOptions) (*LoadResult, error) {
+	if opt.Workers < 1 || opt.Ops < 1 {
+		return nil, errors.New("load: workers and ops must be positive")
+	}
+	sampleCtx, cancel := context.WithTimeout(ctx, opt.OpTimeout)
+	sample, err := repo.ListPage(sampleCtx, 0, maxPageSize)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(sample))
+	for i, t := range sample {
+		ids[i] = t.ID
+	}
+	randomID := func() int {
+		if len(ids) == 0 {
+			return 1
+		}
+		return ids[rand.IntN(len(ids))]
+	}
+
+	var next atomic.Int64
+	results := make([]workerResult, opt.Workers)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := range opt.Workers {
+		wg.Add(1)
+		go func(res *workerResult) {
+			defer wg.Done()
+			for next.Add(1) <= int64(opt.Ops) && ctx.Err() == nil {
+				kind := opFind
+				switch {
+				case rand.Float64() < opt.WriteRatio:
+					kind = opWrite
+				case rand.IntN(2) == 1:
+					kind = opPage
+				}
+				opCtx, cancel := context.WithTimeout(ctx, opt.OpTimeout)
+				t0 := time.Now()
+				err := runOp(opCtx, repo, kind, randomID())
+				res.lat[kind] = append(res.lat[kind], time.Since(t0))
+				cancel()
+				if err != nil {
+					res.errs[kind]++
+					res.lastErr = err
+				}
+			}
+		}(&results[w])
+	}
+	wg.Wait()
+
+	out := &LoadResult{Elapsed: time.Since(start)}
+	for _, r := range results {
+		for k := range opKinds {
+			out.lat[k] = append(out.lat[k], r.lat[k]...)
+			out.errs[k] += r.errs[k]
+		}
+		if r.lastErr != nil {
+			out.lastErr = r.lastErr
+		}
+	}
+	return out, ctx.Err()
+}
+
+func runOp(ctx context.Context, repo TaskRepository, kind opKind, id int) error {
+	switch kind {
+	case opFind:
+		_, err := repo.FindByID(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			return nil // задачу могли удалить — для нагрузки это не ошибка
+		}
+		return err
+	case opPage:
+		_, err := repo.ListPage(ctx, id, 50)
+		return err
+	default:
+		newID, err := repo.CreateTask(ctx, "load test")
+		if err != nil {
+			return err
+		}
+		return repo.Delete(ctx, newID)
+	}
+}
+
+// Print выводит таблицу p50/p90/p99/max по видам операций и в целом.
+func (r *LoadResult) Print(w io.Writer) {
+	fmt.Fprintf(w, "%-6s %8s %7s %10s %10s %10s %10s\n", "op", "count", "errors", "p50", "p90", "p99", "max")
+	var all []time.Duration
+	total, errs := 0, 0
+	for k := range opKinds {
+		if len(r.lat[k]) == 0 {
+			continue
+		}
+		printLatencies(w, opNames[k], r.lat[k], r.errs[k])
+		all = append(all, r.lat[k]...)
+		total += len(r.lat[k])
+		errs += r.errs[k]
+	}
+	printLatencies(w, "all", all, errs)
+	fmt.Fprintf(w, "elapsed %s, %.0f ops/s\n", r.Elapsed.Round(time.Millisecond), float64(total)/r.Elapsed.Seconds())
+	if r.lastErr != nil {
+		fmt.Fprintf(w, "last error: %v\n", r.lastErr)
+	}
+}
+
+func printLatencies(w io.Writer, name string, lat []time.Duration, errs int) {
+	sort.Slice(lat, func(i, j int) bool { return lat[i] < lat[j] })
+	fmt.Fprintf(w, "%-6s %8d %7d %10s %10s %10s %10s\n", name, len(lat), errs,
+		percentile(lat, 0.50), percentile(lat, 0.90), percentile(lat, 0.99), percentile(lat, 1))
+}
+
+// percentile — по ближайшему рангу; lat отсортирован.
+func percentile(lat []time.Duration, p float64) time.Duration {
+	if len(lat) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(lat)))) - 1
+	return lat[max(i, 0)].Round(time.Microsecond)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemRepo — TaskRepository в памяти процесса: для тестов и демо без БД.
+// Задачи хранятся по возрастанию id, id не переиспользуются, как у SERIAL.
+type MemRepo struct {
+	mu     sync.RWMutex
+	tasks  []Task // отсортированы по ID
+	nextID int
+}
+
+var _ TaskRepository = (*MemRepo)(nil)
+
+func NewMemRepo() *MemRepo { return &MemRepo{nextID: 1} }
+
+func (r *MemRepo) CreateTask(ctx context.Context, title string) (int, error) {
+	ids, err := r.CreateMany(ctx, []string{title})
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// CreateMany: названия проверяются до вставки, а вставка идёт под одной
+// блокировкой — частично добавленной пачки никто не увидит.
+func (r *MemRepo) CreateMany(ctx context.Context, titles []string) ([]int, error) {
+	if len(titles) == 0 {
+		return nil, nil
+	}
+	titles, err := checkTitles(titles)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	ids := make([]int, len(titles))
+	for i, t := range titles {
+		ids[i] = r.nextID
+		r.tasks = append(r.tasks, Task{ID: r.nextID, Title: t, CreatedAt: now})
+		r.nextID++
+	}
+	return ids, nil
+}
+
+func (r *MemRepo) ListTasks(ctx context.Context) ([]Task, error) {
+	return r.filter(ctx, func(Task) bool { return true })
+}
+
+func (r *MemRepo) ListDone(ctx context.Context, done bool) ([]Task, error) {
+	return r.filter(ctx, func(t Task) bool { return t.Done == done })
+}
+
+func (r *MemRepo) ListPage(ctx context.Context, afterID, limit int) ([]Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i := sort.Search(len(r.tasks), func(i int) bool { return r.tasks[i].ID > afterID })
+	page := r.tasks[i:min(i+pageLimit(limit), len(r.tasks))]
+	if len(page) == 0 {
+		return nil, nil
+	}
+	return append([]Task(nil), page...), nil
+}
+
+func (r *MemRepo) ListDonePage(ctx context.Context, done bool, afterID, limit int) ([]Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	limit = pageLimit(limit)
+	var page []Task
+	i := sort.Search(len(r.tasks), func(i int) bool { return r.tasks[i].ID > afterID })
+	for ; i < len(r.tasks) && len(page) < limit; i++ {
+		if r.tasks[i].Done == done {
+			page = append(page, r.tasks[i])
+		}
+	}
+	return page, nil
+}
+
+// filter возвращает копии подходящих задач: вызывающий не должен менять r.tasks
+func (r *MemRepo) filter(ctx context.Context, keep func(Task) bool) ([]Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Task
+	for _, t := range r.tasks {
+		if keep(t) {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (r *MemRepo) FindByID(ctx context.Context, id int) (*Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i, ok := r.index(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	t := r.tasks[i]
+	return &t, nil
+}
+
+func (r *MemRepo) MarkDone(ctx context.Context, id int) error {
+	return r.update(ctx, id, func(t *Task) { t.Done = true })
+}
+
+func (r *MemRepo) UpdateTitle(ctx context.Context, id int, title string) error {
+	title, err := checkTitle(title)
+	if err != nil {
+		return err
+	}
+	return r.update(ctx, id, func(t *Task) { t.Title = title })
+}
+
+func (r *MemRepo) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, ok := r.index(id)
+	if !ok {
+		return ErrNotFound
+	}
+	r.tasks = append(r.tasks[:i], r.tasks[i+1:]...)
+	return nil
+}
+
+func (r *MemRepo) update(ctx context.Context, id int, fn func(*Task)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, ok := r.index(id)
+	if !ok {
+		return ErrNotFound
+	}
+	fn(&r.tasks[i])
+	return nil
+}
+
+// index — двоичный поиск по ID; вызывать под r.mu
+func (r *MemRepo) index(id int) (int, bool) {
+	i := sort.Search(len(r.tasks), func(i int) bool { return r.tasks[i].ID >= id })
+	return i, i < len(r.tasks) && r.tasks[i].ID == id
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func file(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+func TestLoadMigrations(t *testing.T) {
+	cases := []struct {
+		name    string
+		fs      fstest.MapFS
+		want    []int  // версии по порядку
+		wantErr string // подстрока ошибки; пусто — без ошибки
+	}{
+		{
+			name: "ok",
+			fs: fstest.MapFS{
+				"0002_priority.up.sql": file("ALTER TABLE tasks ADD priority INT;"),
+				"0001_init.up.sql":     file("CREATE TABLE tasks ();"),
+				"0001_init.down.sql":   file("DROP TABLE tasks;"),
+			},
+			want: []int{1, 2},
+		},
+		{
+			name:    "bad_name",
+			fs:      fstest.MapFS{"init.sql": file("")},
+			wantErr: "name must look like",
+		},
+		{
+			name: "mismatched_names",
+			fs: fstest.MapFS{
+				"0001_init.up.sql":    file("SELECT 1;"),
+				"0001_start.down.sql": file("SELECT 1;"),
+			},
+			wantErr: "names differ",
+		},
+		{
+			name:    "missing_up",
+			fs:      fstest.MapFS{"0001_init.down.sql": file("SELECT 1;")},
+			wantErr: "missing up.sql",
+		},
+		{
+			// иначе второй файл молча перезаписал бы первый
+			name: "duplicate_version",
+			fs: fstest.MapFS{
+				"1_init.up.sql":    file("SELECT 1;"),
+				"0001_init.up.sql": file("SELECT 2;"),
+			},
+			wantErr: "duplicate version",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := loadMigrations(c.fs)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("err = %v; want %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if len(got) != len(c.want) {
+				t.Fatalf("got %d migrations; want versions %v", len(got), c.want)
+			}
+			for i, m := range got {
+				if m.Version != c.want[i] || m.Checksum == "" {
+					t.Fatalf("migration %d = %+v; want version %d with a checksum", i, m, c.want[i])
+				}
+			}
+		})
+	}
+}
+
+// Вшитые миграции должны загружаться — иначе migrate и сервер не стартуют.
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := NewMigrator(nil)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	for i, mig := range m.Migrations {
+		if mig.Version != i+1 {
+			t.Fatalf("migration %04d_%s: want version %d, versions must have no gaps", mig.Version, mig.Name, i+1)
+		}
+		if mig.Down == "" {
+			t.Errorf("migration %04d_%s: missing down.sql", mig.Version, mig.Name)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+-- задачи
+CREATE TABLE IF NOT EXISTS tasks (
+    id         SERIAL PRIMARY KEY,
+    title      TEXT        NOT NULL,
+    done       BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
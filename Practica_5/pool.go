@@ -0,0 +1,89 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// printPoolStats печатает настройки пула и фактическое состояние из sql.DBStats.
+func printPoolStats(w io.Writer, cfg PoolConfig, st sql.DBStats) {
+	fmt.Fprintf(w, "pool settings: max_open=%s max_idle=%d max_lifetime=%s max_idle_time=%s\n",
+		limit(cfg.MaxOpenConns), cfg.MaxIdleConns, durationOrNone(cfg.ConnMaxLifetime), durationOrNone(cfg.ConnMaxIdleTime))
+	fmt.Fprintf(w, "connections:   open=%d in_use=%d idle=%d\n", st.OpenConnections, st.InUse, st.Idle)
+	fmt.Fprintf(w, "waits:         count=%d total=%s\n", st.WaitCount, st.WaitDuration.Round(time.Microsecond))
+	fmt.Fprintf(w, "closed:        max_idle=%d max_idle_time=%d max_lifetime=%d\n",
+		st.MaxIdleClosed, st.MaxIdleTimeClosed, st.MaxLifetimeClosed)
+}
+
+// statsDelta — изменения накопительных счётчиков DBStats за интервал.
+func statsDelta(before, after sql.DBStats) sql.DBStats {
+	d := after
+	d.WaitCount -= before.WaitCount
+	d.WaitDuration -= before.WaitDuration
+	d.MaxIdleClosed -= before.MaxIdleClosed
+	d.MaxIdleTimeClosed -= before.MaxIdleTimeClosed
+	d.MaxLifetimeClosed -= before.MaxLifetimeClosed
+	return d
+}
+
+// poolSampler раз в interval снимает DBStats и запоминает пики —
+// мгновенные open/in_use после нагрузки уже ничего не говорят.
+type poolSampler struct {
+	mu       sync.Mutex
+	maxOpen  int
+	maxInUse int
+	maxWaitQ int64 // наибольший прирост WaitCount за один интервал
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func startPoolSampler(db *sql.DB, interval time.Duration) *poolSampler {
+	s := &poolSampler{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		prev := db.Stats()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-t.C:
+			}
+			st := db.Stats()
+			s.mu.Lock()
+			s.maxOpen = max(s.maxOpen, st.OpenConnections)
+			s.maxInUse = max(s.maxInUse, st.InUse)
+			s.maxWaitQ = max(s.maxWaitQ, st.WaitCount-prev.WaitCount)
+			s.mu.Unlock()
+			prev = st
+		}
+	}()
+	return s
+}
+
+// Stop останавливает сэмплер и возвращает пики: open, in_use, ожиданий за интервал.
+func (s *poolSampler) Stop() (maxOpen, maxInUse int, maxWaits int64) {
+	close(s.stop)
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxOpen, s.maxInUse, s.maxWaitQ
+}
+
+func limit(n int) string {
+	if n <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(n)
+}
+
+func durationOrNone(d time.Duration) string {
+	if d <= 0 {
+		return "none"
+	}
+	return d.String()
+}
@@ -1,37 +1,103 @@
-package Practica_5
+package main
 
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
 // Task — модель для сканирования результатов SELECT
 type Task struct {
-	ID        int
-	Title     string
-	Done      bool
-	CreatedAt time.Time
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Done      bool      `json:"done"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
+// TaskRepository — хранилище задач. Реализации: Repo (PostgreSQL),
+// SQLiteRepo (встроенная SQLite) и MemRepo (в памяти); поведение у всех
+// одинаковое и проверяется общим набором тестов (contract_test.go).
+type TaskRepository interface {
+	CreateTask(ctx context.Context, title string) (int, error)
+	// CreateMany — всё или ничего; ID возвращаются в порядке titles
+	CreateMany(ctx context.Context, titles []string) ([]int, error)
+	ListTasks(ctx context.Context) ([]Task, error)
+	ListDone(ctx context.Context, done bool) ([]Task, error)
+	ListPage(ctx context.Context, afterID, limit int) ([]Task, error)
+	ListDonePage(ctx context.Context, done bool, afterID, limit int) ([]Task, error)
+	FindByID(ctx context.Context, id int) (*Task, error)
+	MarkDone(ctx context.Context, id int) error
+	UpdateTitle(ctx context.Context, id int, title string) error
+	Delete(ctx context.Context, id int) error
+}
+
+var (
+	ErrNotFound   = errors.New("task not found")
+	ErrEmptyTitle = errors.New("task title is empty")
+)
+
+// Repo — TaskRepository поверх PostgreSQL (драйвер pgx/v5/stdlib).
 type Repo struct {
 	DB *sql.DB
 }
 
+var _ TaskRepository = (*Repo)(nil)
+
 func NewRepo(db *sql.DB) *Repo { return &Repo{DB: db} }
 
 // CreateTask — параметризованный INSERT с возвратом id
 func (r *Repo) CreateTask(ctx context.Context, title string) (int, error) {
+	title, err := checkTitle(title)
+	if err != nil {
+		return 0, err
+	}
 	var id int
 	const q = `INSERT INTO tasks (title) VALUES ($1) RETURNING id;`
-	err := r.DB.QueryRowContext(ctx, q, title).Scan(&id)
+	err = r.DB.QueryRowContext(ctx, q, title).Scan(&id)
 	return id, err
 }
 
-// ListTasks — базовый SELECT всех задач (демо для занятия)
-func (r *Repo) ListTasks(ctx context.Context) ([]Task, error) {
-	const q = `SELECT id, title, done, created_at FROM tasks ORDER BY id;`
-	rows, err := r.DB.QueryContext(ctx, q)
+// checkTitle обрезает пробелы; пустое название — ErrEmptyTitle
+func checkTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", ErrEmptyTitle
+	}
+	return title, nil
+}
+
+// checkTitles — checkTitle для пачки; до первой вставки, чтобы не открывать транзакцию зря
+func checkTitles(titles []string) ([]string, error) {
+	out := make([]string, len(titles))
+	for i, t := range titles {
+		var err error
+		if out[i], err = checkTitle(t); err != nil {
+			return nil, fmt.Errorf("titles[%d]: %w", i, err)
+		}
+	}
+	return out, nil
+}
+
+// taskColumns — порядок колонок, который ожидает scanTask
+const taskColumns = `id, title, done, created_at`
+
+// rowScanner — общее у *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(s rowScanner) (Task, error) {
+	var t Task
+	err := s.Scan(&t.ID, &t.Title, &t.Done, &t.CreatedAt)
+	return t, err
+}
+
+// queryTasks выполняет SELECT taskColumns ... и сканирует все строки
+func queryTasks(ctx context.Context, db *sql.DB, q string, args ...any) ([]Task, error) {
+	rows, err := db.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -39,8 +105,8 @@ func (r *Repo) ListTasks(ctx context.Context) ([]Task, error) {
 
 	var out []Task
 	for rows.Next() {
-		var t Task
-		if err := rows.Scan(&t.ID, &t.Title, &t.Done, &t.CreatedAt); err != nil {
+		t, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
 		out = append(out, t)
@@ -48,69 +114,94 @@ func (r *Repo) ListTasks(ctx context.Context) ([]Task, error) {
 	return out, rows.Err()
 }
 
+// ListTasks — базовый SELECT всех задач (демо для занятия);
+// для больших таблиц используйте ListPage
+func (r *Repo) ListTasks(ctx context.Context) ([]Task, error) {
+	return queryTasks(ctx, r.DB, `SELECT `+taskColumns+` FROM tasks ORDER BY id`)
+}
+
 func (r *Repo) ListDone(ctx context.Context, done bool) ([]Task, error) {
-	const q = `SELECT id, title, done, created_at FROM tasks WHERE done = $1 ORDER BY id`
-	rows, err := r.DB.QueryContext(ctx, q, done)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	return queryTasks(ctx, r.DB, `SELECT `+taskColumns+` FROM tasks WHERE done = $1 ORDER BY id`, done)
+}
 
-	var tasks []Task
-	for rows.Next() {
-		var t Task
-		if err := rows.Scan(&t.ID, &t.Title, &t.Done, &t.CreatedAt); err != nil {
-			return nil, err
-		}
-		tasks = append(tasks, t)
-	}
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
 
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
+// ListPage — keyset-пагинация: до limit задач с id > afterID по возрастанию id.
+// Следующая страница — ListPage(ctx, последний id, limit); пустой результат — конец.
+// В отличие от OFFSET, стоимость не растёт с номером страницы (идёт по индексу PK).
+func (r *Repo) ListPage(ctx context.Context, afterID, limit int) ([]Task, error) {
+	limit = pageLimit(limit)
+	const q = `SELECT ` + taskColumns + ` FROM tasks WHERE id > $1 ORDER BY id LIMIT $2`
+	return queryTasks(ctx, r.DB, q, afterID, limit)
+}
 
-	return tasks, nil
+// ListDonePage — то же, что ListPage, но только задачи с заданным done:
+// фильтр работает в SQL, страница не набирается отбрасыванием строк в Go.
+func (r *Repo) ListDonePage(ctx context.Context, done bool, afterID, limit int) ([]Task, error) {
+	limit = pageLimit(limit)
+	const q = `SELECT ` + taskColumns + ` FROM tasks WHERE done = $1 AND id > $2 ORDER BY id LIMIT $3`
+	return queryTasks(ctx, r.DB, q, done, afterID, limit)
 }
 
-// FindByID возвращает задачу по указанному ID
-func (r *Repo) FindByID(ctx context.Context, id int) (*Task, error) {
-	const q = `SELECT id, title, done, created_at FROM tasks WHERE id = $1`
+// pageLimit приводит limit к диапазону 1..maxPageSize; 0 и меньше — defaultPageSize
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPageSize
+	}
+	return min(limit, maxPageSize)
+}
 
-	var task Task
-	err := r.DB.QueryRowContext(ctx, q, id).Scan(&task.ID, &task.Title, &task.Done, &task.CreatedAt)
+// FindByID возвращает задачу по указанному ID или ErrNotFound
+func (r *Repo) FindByID(ctx context.Context, id int) (*Task, error) {
+	const q = `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1`
+	task, err := scanTask(r.DB.QueryRowContext(ctx, q, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
-
 	return &task, nil
 }
 
-// CreateMany выполняет массовую вставку задач через транзакцию
-func (r *Repo) CreateMany(ctx context.Context, titles []string) error {
-	// Начинаем транзакцию
-	tx, err := r.DB.BeginTx(ctx, nil)
+// MarkDone отмечает задачу выполненной; повторный вызов не ошибка
+func (r *Repo) MarkDone(ctx context.Context, id int) error {
+	return execOne(ctx, r.DB, `UPDATE tasks SET done = TRUE WHERE id = $1`, id)
+}
+
+func (r *Repo) UpdateTitle(ctx context.Context, id int, title string) error {
+	title, err := checkTitle(title)
 	if err != nil {
 		return err
 	}
-	// Гарантируем откат транзакции при ошибке
-	defer tx.Rollback()
+	return execOne(ctx, r.DB, `UPDATE tasks SET title = $2 WHERE id = $1`, id, title)
+}
 
-	// Подготавливаем запрос INSERT
-	const query = `INSERT INTO tasks (title) VALUES ($1)`
-	stmt, err := tx.PrepareContext(ctx, query)
+func (r *Repo) Delete(ctx context.Context, id int) error {
+	return execOne(ctx, r.DB, `DELETE FROM tasks WHERE id = $1`, id)
+}
+
+// execOne выполняет UPDATE/DELETE по id; ни одной затронутой строки — ErrNotFound
+func execOne(ctx context.Context, db *sql.DB, q string, args ...any) error {
+	res, err := db.ExecContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
-
-	// Выполняем вставку для каждого заголовка
-	for _, title := range titles {
-		_, err := stmt.ExecContext(ctx, title)
-		if err != nil {
-			return err
-		}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
 	}
+	return nil
+}
 
-	// Фиксируем транзакцию
-	return tx.Commit()
+// CreateMany вставляет задачи одной транзакцией (всё или ничего) и возвращает
+// их ID в порядке titles. Быстрый путь через COPY — см. CreateManyWith в bulk.go.
+func (r *Repo) CreateMany(ctx context.Context, titles []string) ([]int, error) {
+	return r.CreateManyWith(ctx, titles, BulkOptions{})
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "modernc.org/sqlite" // pure Go, без cgo
+)
+
+// sqliteSchema — та же таблица, что в migrations/0001_init.up.sql.
+// AUTOINCREMENT — чтобы id удалённых задач не выдавались повторно, как у SERIAL.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    title      TEXT      NOT NULL,
+    done       BOOLEAN   NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`
+
+// SQLiteRepo — TaskRepository во встроенной SQLite: не нужен сервер БД.
+type SQLiteRepo struct {
+	DB *sql.DB
+}
+
+var _ TaskRepository = (*SQLiteRepo)(nil)
+
+// OpenSQLite открывает (или создаёт) файл базы и схему в нём;
+// path ":memory:" — база в памяти, живёт до Close.
+func OpenSQLite(ctx context.Context, path string) (*SQLiteRepo, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite пишет в один поток, а у ":memory:" каждое соединение — своя база
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite schema: %w", err)
+	}
+	return &SQLiteRepo{DB: db}, nil
+}
+
+func (r *SQLiteRepo) Close() error { return r.DB.Close() }
+
+func (r *SQLiteRepo) CreateTask(ctx context.Context, title string) (int, error) {
+	title, err := checkTitle(title)
+	if err != nil {
+		return 0, err
+	}
+	return sqliteInsert(ctx, r.DB, title)
+}
+
+// execer — общее у *sql.DB и *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, q string, args ...any) (sql.Result, error)
+}
+
+func sqliteInsert(ctx context.Context, db execer, title string) (int, error) {
+	res, err := db.ExecContext(ctx, `INSERT INTO tasks (title) VALUES (?)`, title)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// CreateMany вставляет задачи одной транзакцией; при ошибке откатывается всё
+func (r *SQLiteRepo) CreateMany(ctx context.Context, titles []string) ([]int, error) {
+	if len(titles) == 0 {
+		return nil, nil
+	}
+	titles, err := checkTitles(titles)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, len(titles))
+	for i, t := range titles {
+		if ids[i], err = sqliteInsert(ctx, tx, t); err != nil {
+			return nil, err
+		}
+	}
+	return ids, tx.Commit()
+}
+
+func (r *SQLiteRepo) ListTasks(ctx context.Context) ([]Task, error) {
+	return queryTasks(ctx, r.DB, `SELECT `+taskColumns+` FROM tasks ORDER BY id`)
+}
+
+func (r *SQLiteRepo) ListDone(ctx context.Context, done bool) ([]Task, error) {
+	return queryTasks(ctx, r.DB, `SELECT `+taskColumns+` FROM tasks WHERE done = ? ORDER BY id`, done)
+}
+
+func (r *SQLiteRepo) ListPage(ctx context.Context, afterID, limit int) ([]Task, error) {
+	const q = `SELECT ` + taskColumns + ` FROM tasks WHERE id > ? ORDER BY id LIMIT ?`
+	return queryTasks(ctx, r.DB, q, afterID, pageLimit(limit))
+}
+
+func (r *SQLiteRepo) ListDonePage(ctx context.Context, done bool, afterID, limit int) ([]Task, error) {
+	const q = `SELECT ` + taskColumns + ` FROM tasks WHERE done = ? AND id > ? ORDER BY id LIMIT ?`
+	return queryTasks(ctx, r.DB, q, done, afterID, pageLimit(limit))
+}
+
+func (r *SQLiteRepo) FindByID(ctx context.Context, id int) (*Task, error) {
+	const q = `SELECT ` + taskColumns + ` FROM tasks WHERE id = ?`
+	task, err := scanTask(r.DB.QueryRowContext(ctx, q, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *SQLiteRepo) MarkDone(ctx context.Context, id int) error {
+	return execOne(ctx, r.DB, `UPDATE tasks SET done = TRUE WHERE id = ?`, id)
+}
+
+func (r *SQLiteRepo) UpdateTitle(ctx context.Context, id int, title string) error {
+	title, err := checkTitle(title)
+	if err != nil {
+		return err
+	}
+	return execOne(ctx, r.DB, `UPDATE tasks SET title = ? WHERE id = ?`, title, id)
+}
+
+func (r *SQLiteRepo) Delete(ctx context.Context, id int) error {
+	return execOne(ctx, r.DB, `DELETE FROM tasks WHERE id = ?`, id)
+}
@@ -1,24 +1,79 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"example.com/prac_6/internal/db"
 	"example.com/prac_6/internal/http"
-	"example.com/prac_6/internal/models"
+	"example.com/prac_6/internal/migrate"
+	"example.com/prac_6/internal/trash"
 )
 
+// server            — запустить HTTP-сервер
+// server migrate …  — миграции схемы, см. migrate.go
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+	serve()
+}
+
+func serve() {
 	d := db.Connect()
 
-	// Автоматически создаст (или обновит) таблицы под наши модели
-	if err := d.AutoMigrate(&models.User{}, &models.Note{}, &models.Tag{}); err != nil {
-		log.Fatal("migrate:", err)
+	// Схему меняет только `migrate up`; со старой схемой сервер не стартует
+	sqlDB, err := d.DB()
+	if err != nil {
+		log.Fatal(err)
 	}
+	m, err := migrate.NewMigrator(sqlDB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	checkCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	err = m.Check(checkCtx)
+	cancel()
+	if err != nil {
+		log.Fatalf("schema check: %v (run: go run ./cmd/server migrate up)", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	r := httpapi.BuildRouter(d)
+	// Корзина: всё, что удалено раньше TRASH_RETENTION, удаляется окончательно
+	purger := trash.NewPurger(d, envDuration("TRASH_RETENTION", 30*24*time.Hour))
+	purger.Interval = envDuration("TRASH_PURGE_INTERVAL", time.Hour)
+	go purger.Run(ctx)
+
+	srv := &http.Server{Addr: ":8080", Handler: httpapi.BuildRouter(d)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
 
 	log.Println("listening on :8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+// envDuration читает длительность вида 720h из окружения; пусто — def
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Fatalf("%s: want a positive duration like 720h, got %q", name, v)
+	}
+	return d
 }
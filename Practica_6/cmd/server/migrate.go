@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"example.com/prac_6/internal/db"
+	"example.com/prac_6/internal/migrate"
+	"example.com/prac_6/internal/models"
+)
+
+const migrateUsage = `Использование: server migrate [up | down N | status | diff]
+
+  up       применить все новые миграции (по умолчанию)
+  down N   откатить N последних миграций
+  status   список миграций и когда они применены
+  diff     SQL, которого схеме не хватает до моделей GORM (ничего не меняет)
+`
+
+var errMigrateUsage = errors.New("usage")
+
+// runMigrate возвращает код выхода: 0 — успех, 1 — ошибка, 2 — неверные аргументы.
+func runMigrate(args []string) int {
+	err := migrateCmd(args)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errMigrateUsage):
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	default:
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+}
+
+func migrateCmd(args []string) error {
+	cmd, n := "up", 1
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+	switch {
+	case cmd == "down" && len(args) == 2:
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 1 {
+			return errMigrateUsage
+		}
+		n = v
+	case cmd != "up" && cmd != "down" && cmd != "status" && cmd != "diff",
+		len(args) > 2, len(args) == 2 && cmd != "down":
+		return errMigrateUsage
+	}
+
+	d := db.Connect()
+	sqlDB, err := d.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if cmd == "diff" {
+		stmts, err := migrate.Diff(ctx, d, models.All()...)
+		if err != nil {
+			return err
+		}
+		if len(stmts) == 0 {
+			fmt.Println("-- schema matches the models")
+			return nil
+		}
+		fmt.Println("-- review, then save as internal/migrate/migrations/NNNN_name.up.sql (and .down.sql)")
+		for _, s := range stmts {
+			fmt.Printf("%s;\n", s)
+		}
+		return nil
+	}
+
+	m, err := migrate.NewMigrator(sqlDB)
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "up":
+		done, err := m.Up(ctx)
+		for _, v := range done {
+			fmt.Printf("migration %04d applied\n", v)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+	case "down":
+		done, err := m.Down(ctx, n)
+		for _, v := range done {
+			fmt.Printf("migration %04d rolled back\n", v)
+		}
+		return err
+	default: // status
+		st, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range st {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Modified {
+				state += " (MODIFIED)"
+			}
+			fmt.Printf("%04d_%-24s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	}
+}
@@ -2,10 +2,14 @@ package httpapi
 
 import (
 	"encoding/json"
+	"errors"
 	"example.com/prac_6/internal/models"
+	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Handlers struct{ db *gorm.DB }
@@ -35,6 +39,35 @@ func (h *Handlers) CreateUser(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, u)
 }
 
+// ListUserNotes — заметки пользователя (через связь User.Notes) с тегами
+func (h *Handlers) ListUserNotes(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(w, r)
+	if !ok {
+		return
+	}
+	var u models.User
+	err := h.db.
+		Preload("Notes", func(db *gorm.DB) *gorm.DB { return db.Order("notes.id") }).
+		Preload("Notes.Tags", func(db *gorm.DB) *gorm.DB { return db.Order("tags.name") }).
+		First(&u, id).Error
+	if err != nil {
+		writeDBErr(w, err, "user not found")
+		return
+	}
+	notes := u.Notes
+	u.Notes = nil
+	for i := range notes {
+		notes[i].User = u // автор у всех один — без лишнего Preload
+	}
+	if notes == nil {
+		notes = []models.Note{}
+	}
+	writeJSON(w, http.StatusOK, notes)
+}
+
+// errUserNotFound — userId в теле запроса не соответствует пользователю
+var errUserNotFound = errors.New("user not found")
+
 type createNoteReq struct {
 	Title   string   `json:"title"`
 	Content string   `json:"content"`
@@ -42,34 +75,47 @@ type createNoteReq struct {
 	Tags    []string `json:"tags"` // имена тегов
 }
 
+// CreateNote создаёт заметку и недостающие теги одной транзакцией:
+// либо сохранено всё, либо ничего. Ошибка в имени тега — 400, а не пропуск тега.
 func (h *Handlers) CreateNote(w http.ResponseWriter, r *http.Request) {
 	var in createNoteReq
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Title == "" || in.UserID == 0 {
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.Title) == "" || in.UserID == 0 {
 		writeErr(w, http.StatusBadRequest, "title and userId are required")
 		return
 	}
-
-	// Находим/создаём теги
-	var tags []models.Tag
-	for _, name := range in.Tags {
-		if name == "" {
-			continue
-		}
-		t := models.Tag{Name: name}
-		if err := h.db.FirstOrCreate(&t, models.Tag{Name: name}).Error; err == nil {
-			tags = append(tags, t)
-		}
+	names, err := normalizeTags(in.Tags)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	note := models.Note{
-		Title:   in.Title,
+		Title:   strings.TrimSpace(in.Title),
 		Content: in.Content,
 		UserID:  in.UserID,
-		Tags:    tags,
 	}
-	if err := h.db.Create(&note).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&models.User{}, in.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errUserNotFound
+			}
+			return err
+		}
+		tags, err := upsertTags(tx, names)
+		if err != nil {
+			return err
+		}
+		note.Tags = tags
+		// теги уже есть в БД — создаём только строки note_tags
+		return tx.Omit("Tags.*").Create(&note).Error
+	})
+	switch {
+	case errors.Is(err, errUserNotFound):
 		writeErr(w, http.StatusBadRequest, err.Error())
 		return
+	case err != nil:
+		writeErr(w, http.StatusInternalServerError, "create note: "+err.Error())
+		return
 	}
 	// Вернём с автором и тегами
 	if err := h.db.Preload("User").Preload("Tags").First(&note, note.ID).Error; err != nil {
@@ -94,6 +140,146 @@ func (h *Handlers) GetNoteByID(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, note)
 }
 
+const (
+	defaultNotesLimit = 20
+	maxNotesLimit     = 100
+)
+
+// ListNotes — GET /notes?limit=&offset=&author=&tags=go,db&match=all|any&exclude_tags=
+// Заметки выбираются одним запросом (см. noteFilter.apply), авторы и теги
+// страницы — двумя Preload на всю страницу. Общее число — в заголовке X-Total-Count.
+func (h *Handlers) ListNotes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, err := intParam(q.Get("limit"), defaultNotesLimit)
+	if err != nil || limit < 1 {
+		writeErr(w, http.StatusBadRequest, "bad limit")
+		return
+	}
+	limit = min(limit, maxNotesLimit)
+	offset, err := intParam(q.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		writeErr(w, http.StatusBadRequest, "bad offset")
+		return
+	}
+	f, err := parseNoteFilter(q)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var total int64
+	err = h.db.Table("(?) AS f", f.apply(h.db).Select("notes.id")).Count(&total).Error
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	notes := []models.Note{}
+	err = f.apply(h.db).Select("notes.*").
+		Preload("User").
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("tags.name") }).
+		Order("notes.id").Limit(limit).Offset(offset).
+		Find(&notes).Error
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	writeJSON(w, http.StatusOK, notes)
+}
+
+// updateNoteReq — поля PATCH; отсутствующее поле не меняется.
+// tags заменяет набор тегов целиком, [] — снять все теги.
+type updateNoteReq struct {
+	Title   *string   `json:"title"`
+	Content *string   `json:"content"`
+	Tags    *[]string `json:"tags"`
+}
+
+func (h *Handlers) UpdateNote(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(w, r)
+	if !ok {
+		return
+	}
+	var in updateNoteReq
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if in.Title != nil && strings.TrimSpace(*in.Title) == "" {
+		writeErr(w, http.StatusBadRequest, "title must not be empty")
+		return
+	}
+	var names []string
+	if in.Tags != nil {
+		var err error
+		if names, err = normalizeTags(*in.Tags); err != nil {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	var note models.Note
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&note, id).Error; err != nil {
+			return err
+		}
+		upd := map[string]any{}
+		if in.Title != nil {
+			upd["title"] = strings.TrimSpace(*in.Title)
+		}
+		if in.Content != nil {
+			upd["content"] = *in.Content
+		}
+		if in.Tags != nil {
+			tags, err := upsertTags(tx, names)
+			if err != nil {
+				return err
+			}
+			if len(tags) == 0 {
+				err = tx.Model(&note).Association("Tags").Clear()
+			} else {
+				err = tx.Model(&note).Association("Tags").Replace(tags)
+			}
+			if err != nil {
+				return err
+			}
+			upd["updated_at"] = time.Now() // смена тегов — тоже изменение заметки
+		}
+		if len(upd) == 0 {
+			return nil
+		}
+		return tx.Model(&note).Updates(upd).Error
+	})
+	if err != nil {
+		writeDBErr(w, err, "note not found")
+		return
+	}
+	if err := h.db.Preload("User").Preload("Tags").First(&note, id).Error; err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, note)
+}
+
+// DeleteNote переносит заметку в корзину (мягкое удаление): связи с тегами
+// остаются, POST /notes/{id}/restore вернёт её как была
+func (h *Handlers) DeleteNote(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(w, r)
+	if !ok {
+		return
+	}
+	res := h.db.Delete(&models.Note{}, id)
+	if res.Error != nil {
+		writeErr(w, http.StatusInternalServerError, res.Error.Error())
+		return
+	}
+	if res.RowsAffected == 0 {
+		writeErr(w, http.StatusNotFound, "note not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // helpers (единый JSON-ответ)
 type jsonErr struct {
 	Error string `json:"error"`
@@ -107,3 +293,30 @@ func writeJSON(w http.ResponseWriter, code int, v any) {
 func writeErr(w http.ResponseWriter, code int, msg string) {
 	writeJSON(w, code, jsonErr{Error: msg})
 }
+
+// writeDBErr: запись не найдена — 404 с notFound, остальное — 500
+func writeDBErr(w http.ResponseWriter, err error, notFound string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeErr(w, http.StatusNotFound, notFound)
+		return
+	}
+	writeErr(w, http.StatusInternalServerError, err.Error())
+}
+
+// idParam разбирает {id} из пути; при ошибке уже ответил 400
+func idParam(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil || id == 0 {
+		writeErr(w, http.StatusBadRequest, "bad id")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// intParam — число из query; пустая строка — def
+func intParam(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	return strconv.Atoi(s)
+}
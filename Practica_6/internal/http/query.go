@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"errors"
+	"example.com/prac_6/internal/models"
+	"fmt"
+	"gorm.io/gorm"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// noteFilter — фильтры GET /notes по автору и тегам.
+type noteFilter struct {
+	Author   uint
+	Tags     []string // нормализованные имена
+	MatchAll bool     // true — заметка должна иметь все Tags, false — хотя бы один
+	Exclude  []string // заметки с любым из этих тегов не попадают в выборку
+}
+
+// parseNoteFilter разбирает author, tags (и одиночный tag), match и exclude_tags.
+func parseNoteFilter(q url.Values) (noteFilter, error) {
+	var f noteFilter
+	if s := q.Get("author"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || id == 0 {
+			return f, errors.New("bad author")
+		}
+		f.Author = uint(id)
+	}
+
+	var err error
+	if f.Tags, err = tagList(q.Get("tags") + "," + q.Get("tag")); err != nil {
+		return f, fmt.Errorf("tags: %w", err)
+	}
+	if f.Exclude, err = tagList(q.Get("exclude_tags")); err != nil {
+		return f, fmt.Errorf("exclude_tags: %w", err)
+	}
+	switch q.Get("match") {
+	case "", "all":
+		f.MatchAll = true
+	case "any":
+	default:
+		return f, errors.New("match must be all or any")
+	}
+	return f, nil
+}
+
+// tagList — имена тегов через запятую; пустые элементы пропускаются
+func tagList(s string) ([]string, error) {
+	var names []string
+	for _, n := range strings.Split(s, ",") {
+		if strings.TrimSpace(n) != "" {
+			names = append(names, n)
+		}
+	}
+	return normalizeTags(names)
+}
+
+// apply строит выборку заметок одним запросом: теги — через JOIN note_tags/tags
+// с GROUP BY (для match=all — HAVING по числу совпавших тегов), исключения —
+// через NOT EXISTS. Колонки выборки задаёт вызывающий (Select).
+func (f noteFilter) apply(db *gorm.DB) *gorm.DB {
+	db = db.Model(&models.Note{})
+	if f.Author > 0 {
+		db = db.Where("notes.user_id = ?", f.Author)
+	}
+	if len(f.Tags) > 0 {
+		db = db.
+			Joins("JOIN note_tags ON note_tags.note_id = notes.id").
+			Joins("JOIN tags ON tags.id = note_tags.tag_id AND tags.name IN ?", f.Tags).
+			Group("notes.id")
+		if f.MatchAll && len(f.Tags) > 1 {
+			db = db.Having("COUNT(DISTINCT tags.id) = ?", len(f.Tags))
+		}
+	}
+	if len(f.Exclude) > 0 {
+		db = db.Where(`NOT EXISTS (
+			SELECT 1 FROM note_tags x
+			JOIN tags xt ON xt.id = x.tag_id
+			WHERE x.note_id = notes.id AND xt.name IN ?)`, f.Exclude)
+	}
+	return db
+}
@@ -13,10 +13,25 @@ func BuildRouter(d *gorm.DB) *chi.Mux {
 
 	// Пользователи (упрощённо)
 	r.Post("/users", h.CreateUser)
+	r.Get("/users/{id}/notes", h.ListUserNotes) // заметки пользователя с тегами
+	r.Delete("/users/{id}", h.DeleteUser)       // в корзину вместе со всеми заметками
+	r.Post("/users/{id}/restore", h.RestoreUser)
 
 	// Заметки
-	r.Post("/notes", h.CreateNote)      // создаём заметку с тегами
-	r.Get("/notes/{id}", h.GetNoteByID) // получаем заметку с автором и тегами
+	r.Get("/notes", h.ListNotes)          // страница заметок; author, tags+match, exclude_tags
+	r.Post("/notes", h.CreateNote)        // создаём заметку с тегами
+	r.Get("/notes/{id}", h.GetNoteByID)   // получаем заметку с автором и тегами
+	r.Patch("/notes/{id}", h.UpdateNote)  // меняем поля и/или весь набор тегов
+	r.Delete("/notes/{id}", h.DeleteNote) // переносим заметку в корзину
+	r.Post("/notes/{id}/restore", h.RestoreNote)
+
+	// Теги
+	r.Get("/tags", h.ListTags)          // ?unused=true — теги без заметок
+	r.Get("/tags/stats", h.TagStats)    // облако тегов: число заметок у каждого
+	r.Delete("/tags/{id}", h.DeleteTag) // ?force=true — снять с заметок и удалить
+
+	// Корзина; окончательно удаляет trash.Purger по истечении срока хранения
+	r.Get("/trash", h.Trash)
 
 	return r
 }
@@ -0,0 +1,160 @@
+package httpapi
+
+import (
+	"errors"
+	"example.com/prac_6/internal/models"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTagLen — как size:50 у models.Tag.Name
+const maxTagLen = 50
+
+// normalizeTag приводит имя тега к каноническому виду: нижний регистр,
+// без пробелов по краям, пробелы внутри схлопнуты в один ("  Go  Lang" → "go lang").
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// normalizeTags нормализует имена, убирает повторы и сортирует — в одном
+// порядке вставки параллельные транзакции не блокируют друг друга крест-накрест.
+// Пустое или слишком длинное имя — ошибка.
+func normalizeTags(names []string) ([]string, error) {
+	out := make([]string, 0, len(names))
+	for i, name := range names {
+		n := normalizeTag(name)
+		if n == "" {
+			return nil, fmt.Errorf("tags[%d]: empty tag name", i)
+		}
+		if utf8.RuneCountInString(n) > maxTagLen {
+			return nil, fmt.Errorf("tags[%d]: tag name longer than %d characters", i, maxTagLen)
+		}
+		out = append(out, n)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// upsertTags создаёт недостающие теги и возвращает все теги с именами names
+// (уже нормализованными). INSERT ... ON CONFLICT (name) DO NOTHING не падает на
+// уникальном индексе, если тот же новый тег одновременно создаёт другой запрос:
+// INSERT дождётся его транзакции, а повторный SELECT найдёт её строку.
+func upsertTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	rows := make([]models.Tag, len(names))
+	for i, n := range names {
+		rows[i] = models.Tag{Name: n}
+	}
+	err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+		Create(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("upsert tags: %w", err)
+	}
+	var tags []models.Tag
+	if err := tx.Where("name IN ?", names).Order("name").Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("select tags: %w", err)
+	}
+	if len(tags) != len(names) {
+		// тег удалили между INSERT и SELECT — лучше ошибка, чем заметка без тега
+		return nil, fmt.Errorf("upsert tags: found %d of %d tags", len(tags), len(names))
+	}
+	return tags, nil
+}
+
+// ListTags — все теги по имени; ?unused=true — только теги без живых заметок
+// (остаются после PATCH и DELETE заметок, их можно удалить без force).
+// Заметки в корзине тег не «занимают».
+func (h *Handlers) ListTags(w http.ResponseWriter, r *http.Request) {
+	q := h.db.Order("name")
+	if r.URL.Query().Get("unused") == "true" {
+		q = q.Where(`NOT EXISTS (
+			SELECT 1 FROM note_tags
+			JOIN notes ON notes.id = note_tags.note_id AND notes.deleted_at IS NULL
+			WHERE note_tags.tag_id = tags.id)`)
+	}
+	tags := []models.Tag{}
+	if err := q.Find(&tags).Error; err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// tagStat — строка облака тегов
+type tagStat struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Notes int64  `json:"notes"`
+}
+
+// TagStats — GET /tags/stats?limit=N: теги с числом заметок, самые частые первыми.
+// Считается одним агрегирующим запросом по note_tags; заметки в корзине
+// не учитываются, теги без заметок — с 0.
+func (h *Handlers) TagStats(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r.URL.Query().Get("limit"), 0)
+	if err != nil || limit < 0 {
+		writeErr(w, http.StatusBadRequest, "bad limit")
+		return
+	}
+	q := h.db.Table("tags").
+		Select("tags.id, tags.name, COUNT(notes.id) AS notes").
+		Joins("LEFT JOIN note_tags ON note_tags.tag_id = tags.id").
+		Joins("LEFT JOIN notes ON notes.id = note_tags.note_id AND notes.deleted_at IS NULL").
+		Group("tags.id, tags.name").
+		Order("notes DESC, tags.name")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	stats := []tagStat{}
+	if err := q.Scan(&stats).Error; err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// errTagInUse — тег ещё привязан к заметкам, а ?force=true не передан
+var errTagInUse = errors.New("tag is attached to notes")
+
+// DeleteTag удаляет тег. Если тег привязан к заметкам, по умолчанию — 409
+// с числом заметок; с ?force=true тег сначала снимается со всех заметок.
+// Строк note_tags, ссылающихся на удалённый тег, не остаётся в обоих случаях.
+// Заметки в корзине не считаются, но и с них тег снимается.
+func (h *Handlers) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(w, r)
+	if !ok {
+		return
+	}
+	force := r.URL.Query().Get("force") == "true"
+
+	var used int64
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.First(&tag, id).Error; err != nil {
+			return err
+		}
+		used = tx.Model(&tag).Association("Notes").Count()
+		if used > 0 && !force {
+			return errTagInUse
+		}
+		return tx.Select("Notes").Delete(&tag).Error
+	})
+	switch {
+	case errors.Is(err, errTagInUse):
+		writeJSON(w, http.StatusConflict, map[string]any{
+			"error": "tag is attached to notes; use ?force=true to detach and delete",
+			"notes": used,
+		})
+	case err != nil:
+		writeDBErr(w, err, "tag not found")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,123 @@
+package httpapi
+
+import (
+	"errors"
+	"example.com/prac_6/internal/models"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// errAuthorTrashed — заметку нельзя восстановить отдельно от удалённого автора
+var errAuthorTrashed = errors.New("author is in trash; restore the user instead")
+
+// DeleteUser переносит в корзину пользователя и все его заметки. У всех них
+// ставится один и тот же deleted_at — по нему RestoreUser вернёт ровно эту пачку,
+// а заметки, удалённые раньше по одной, останутся в корзине. Email остаётся
+// занятым (idx_users_email без учёта deleted_at): повторная регистрация — 409.
+func (h *Handlers) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(w, r)
+	if !ok {
+		return
+	}
+	// timestamptz хранит микросекунды — обрезаем, чтобы сравнение при восстановлении было точным
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	var notes int64
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&models.User{}, id).Error; err != nil {
+			return err
+		}
+		// Model(&Note{}) не трогает заметки, уже лежащие в корзине
+		res := tx.Model(&models.Note{}).Where("user_id = ?", id).Update("deleted_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		notes = res.RowsAffected
+		return tx.Model(&models.User{}).Where("id = ?", id).Update("deleted_at", now).Error
+	})
+	if err != nil {
+		writeDBErr(w, err, "user not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deletedAt": now, "notes": notes})
+}
+
+// RestoreUser возвращает из корзины пользователя и заметки, удалённые вместе с ним
+func (h *Handlers) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(w, r)
+	if !ok {
+		return
+	}
+	var u models.User
+	var notes int64
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&u, id).Error; err != nil {
+			return err
+		}
+		res := tx.Unscoped().Model(&models.Note{}).
+			Where("user_id = ? AND deleted_at = ?", id, u.DeletedAt.Time).
+			Update("deleted_at", nil)
+		if res.Error != nil {
+			return res.Error
+		}
+		notes = res.RowsAffected
+		if err := tx.Unscoped().Model(&u).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		u.DeletedAt = gorm.DeletedAt{}
+		return nil
+	})
+	if err != nil {
+		writeDBErr(w, err, "user not in trash")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"user": u, "notes": notes})
+}
+
+// RestoreNote возвращает заметку из корзины вместе с её тегами
+func (h *Handlers) RestoreNote(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(w, r)
+	if !ok {
+		return
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var note models.Note
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&note, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Select("id").First(&models.User{}, note.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errAuthorTrashed
+			}
+			return err
+		}
+		return tx.Unscoped().Model(&note).Update("deleted_at", nil).Error
+	})
+	switch {
+	case errors.Is(err, errAuthorTrashed):
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		writeDBErr(w, err, "note not in trash")
+		return
+	}
+	h.GetNoteByID(w, r)
+}
+
+// Trash — GET /trash: удалённые пользователи и заметки, последние удалённые первыми
+func (h *Handlers) Trash(w http.ResponseWriter, r *http.Request) {
+	users := []models.User{}
+	notes := []models.Note{}
+	err := h.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC, id").Find(&users).Error
+	if err == nil {
+		err = h.db.Unscoped().Where("deleted_at IS NOT NULL").
+			Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+			Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("tags.name") }).
+			Order("deleted_at DESC, id").Find(&notes).Error
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"users": users, "notes": notes})
+}
@@ -0,0 +1,103 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/prac_6/internal/models"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB — SQLite в памяти со схемой из моделей; своя база на каждый тест.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	d, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := d.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := d.AutoMigrate(models.All()...); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	return d
+}
+
+// do выполняет запрос к роутеру и разбирает JSON-ответ в out (если не nil).
+func do(t *testing.T, h http.Handler, method, path, body string, out any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decode %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestDeleteRestoreUser(t *testing.T) {
+	d := openTestDB(t)
+	h := BuildRouter(d)
+
+	var u models.User
+	if code := do(t, h, "POST", "/users", `{"name":"Ann","email":"ann@example.com"}`, &u); code != http.StatusCreated {
+		t.Fatalf("create user: %d", code)
+	}
+	var kept, early models.Note
+	do(t, h, "POST", "/notes", `{"title":"kept","userId":1,"tags":["go"]}`, &kept)
+	do(t, h, "POST", "/notes", `{"title":"early","userId":1}`, &early)
+	// удалена по одной раньше пользователя — вместе с ним не восстанавливается
+	if code := do(t, h, "DELETE", "/notes/2", "", nil); code != http.StatusNoContent {
+		t.Fatalf("delete note: %d", code)
+	}
+
+	var del struct{ Notes int64 }
+	if code := do(t, h, "DELETE", "/users/1", "", &del); code != http.StatusOK || del.Notes != 1 {
+		t.Fatalf("delete user: %d, notes=%d; want 200, 1", code, del.Notes)
+	}
+	if code := do(t, h, "GET", "/notes/1", "", nil); code != http.StatusNotFound {
+		t.Fatalf("note of trashed user: %d; want 404", code)
+	}
+	// email удалённого пользователя всё ещё занят уникальным индексом
+	if code := do(t, h, "POST", "/users", `{"name":"Ann","email":"ann@example.com"}`, nil); code != http.StatusConflict {
+		t.Fatalf("re-register trashed email: %d; want 409", code)
+	}
+	// заметку нельзя вернуть отдельно от автора
+	if code := do(t, h, "POST", "/notes/1/restore", "", nil); code != http.StatusConflict {
+		t.Fatalf("restore note of trashed user: %d; want 409", code)
+	}
+
+	var res struct{ Notes int64 }
+	if code := do(t, h, "POST", "/users/1/restore", "", &res); code != http.StatusOK || res.Notes != 1 {
+		t.Fatalf("restore user: %d, notes=%d; want 200, 1", code, res.Notes)
+	}
+	var note models.Note
+	if code := do(t, h, "GET", "/notes/1", "", &note); code != http.StatusOK || len(note.Tags) != 1 {
+		t.Fatalf("restored note: %d, tags=%v; want 200 with its tag", code, note.Tags)
+	}
+	var trash struct {
+		Users []models.User
+		Notes []models.Note
+	}
+	do(t, h, "GET", "/trash", "", &trash)
+	if len(trash.Users) != 0 || len(trash.Notes) != 1 || trash.Notes[0].Title != "early" {
+		t.Fatalf("trash after restore: %+v; want only the early note", trash)
+	}
+	if code := do(t, h, "POST", "/users/1/restore", "", nil); code != http.StatusNotFound {
+		t.Fatalf("restore live user: %d; want 404", code)
+	}
+}
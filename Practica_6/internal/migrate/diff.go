@@ -0,0 +1,90 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// errDiffRollback откатывает транзакцию Diff — ничего из неё не сохраняется.
+var errDiffRollback = errors.New("diff: rollback")
+
+// Diff сравнивает модели GORM с текущей схемой и возвращает SQL, которого
+// не хватает схеме до моделей. Для этого AutoMigrate выполняется внутри
+// транзакции, которая всегда откатывается (DDL в PostgreSQL транзакционный),
+// а его CREATE/ALTER/DROP записываются. AutoMigrate ничего не удаляет,
+// поэтому колонки, которых нет в моделях, добавляются закомментированными
+// DROP COLUMN — их нужно проверить вручную.
+//
+// Результат — заготовка для новой миграции, а не готовая миграция: её нужно
+// просмотреть и дополнить down-скриптом.
+func Diff(ctx context.Context, db *gorm.DB, models ...any) ([]string, error) {
+	rec := &ddlRecorder{}
+	var extra []string
+	err := db.Session(&gorm.Session{Logger: rec, Context: ctx}).Transaction(func(tx *gorm.DB) error {
+		rec.on = true
+		err := tx.AutoMigrate(models...)
+		rec.on = false
+		if err != nil {
+			return err
+		}
+		if extra, err = extraColumns(tx, models); err != nil {
+			return err
+		}
+		return errDiffRollback
+	})
+	if !errors.Is(err, errDiffRollback) {
+		return nil, err
+	}
+	return append(rec.stmts, extra...), nil
+}
+
+// extraColumns — колонки таблиц моделей, которых нет среди полей моделей.
+func extraColumns(tx *gorm.DB, models []any) ([]string, error) {
+	var out []string
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		cols, err := tx.Migrator().ColumnTypes(model)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cols {
+			if stmt.Schema.LookUpField(c.Name()) == nil {
+				out = append(out, fmt.Sprintf("-- column is not in the model, review before dropping:\n-- ALTER TABLE %q DROP COLUMN %q",
+					stmt.Schema.Table, c.Name()))
+			}
+		}
+	}
+	return out, nil
+}
+
+// ddlRecorder — logger GORM, который запоминает DDL, выполненный при on.
+type ddlRecorder struct {
+	on    bool
+	stmts []string
+}
+
+func (r *ddlRecorder) LogMode(logger.LogLevel) logger.Interface { return r }
+func (r *ddlRecorder) Info(context.Context, string, ...any)     {}
+func (r *ddlRecorder) Warn(context.Context, string, ...any)     {}
+func (r *ddlRecorder) Error(context.Context, string, ...any)    {}
+
+func (r *ddlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), err error) {
+	if !r.on || err != nil {
+		return
+	}
+	sql, _ := fc()
+	verb, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	switch strings.ToUpper(verb) {
+	case "CREATE", "ALTER", "DROP", "COMMENT":
+		r.stmts = append(r.stmts, sql)
+	}
+}
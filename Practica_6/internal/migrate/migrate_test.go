@@ -0,0 +1,174 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func file(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+func TestLoadMigrations(t *testing.T) {
+	cases := []struct {
+		name    string
+		fs      fstest.MapFS
+		want    []int  // версии по порядку
+		wantErr string // подстрока ошибки; пусто — без ошибки
+	}{
+		{
+			name: "ok",
+			fs: fstest.MapFS{
+				"0002_tags.up.sql":   file("CREATE TABLE tags ();"),
+				"0001_init.up.sql":   file("CREATE TABLE users ();"),
+				"0001_init.down.sql": file("DROP TABLE users;"),
+			},
+			want: []int{1, 2},
+		},
+		{
+			name: "empty",
+			fs:   fstest.MapFS{},
+			want: []int{},
+		},
+		{
+			name:    "bad_name",
+			fs:      fstest.MapFS{"init.sql": file("")},
+			wantErr: "name must look like",
+		},
+		{
+			name:    "bad_direction",
+			fs:      fstest.MapFS{"0001_init.sideways.sql": file("")},
+			wantErr: "name must look like",
+		},
+		{
+			name: "mismatched_names",
+			fs: fstest.MapFS{
+				"0001_init.up.sql":    file("SELECT 1;"),
+				"0001_start.down.sql": file("SELECT 1;"),
+			},
+			wantErr: "names differ",
+		},
+		{
+			name:    "missing_up",
+			fs:      fstest.MapFS{"0001_init.down.sql": file("SELECT 1;")},
+			wantErr: "missing up.sql",
+		},
+		{
+			name: "duplicate_version",
+			fs: fstest.MapFS{
+				"1_init.up.sql":    file("SELECT 1;"),
+				"0001_init.up.sql": file("SELECT 2;"),
+			},
+			wantErr: "duplicate version",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := loadMigrations(c.fs)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("err = %v; want %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			versions := make([]int, 0, len(got))
+			for _, m := range got {
+				versions = append(versions, m.Version)
+				if m.Checksum == "" {
+					t.Errorf("migration %d: empty checksum", m.Version)
+				}
+			}
+			if len(versions) != len(c.want) {
+				t.Fatalf("versions = %v; want %v", versions, c.want)
+			}
+			for i := range versions {
+				if versions[i] != c.want[i] {
+					t.Fatalf("versions = %v; want %v", versions, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMigrations_Fields(t *testing.T) {
+	got, err := loadMigrations(fstest.MapFS{
+		"0001_init.up.sql":   file("up"),
+		"0001_init.down.sql": file("down"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	m := got[0]
+	if m.Name != "init" || m.Up != "up" || m.Down != "down" {
+		t.Fatalf("got %+v", m)
+	}
+	// checksum считается только по up: правка down.sql не ломает применённую миграцию
+	again, _ := loadMigrations(fstest.MapFS{
+		"0001_init.up.sql":   file("up"),
+		"0001_init.down.sql": file("other down"),
+	})
+	if again[0].Checksum != m.Checksum {
+		t.Fatalf("checksum depends on down.sql")
+	}
+}
+
+// Вшитые миграции должны загружаться — иначе сервер не стартует.
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := NewMigrator(nil)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	for i, mig := range m.Migrations {
+		if mig.Version != i+1 {
+			t.Fatalf("migration %04d_%s: want version %d, versions must have no gaps", mig.Version, mig.Name, i+1)
+		}
+		if mig.Down == "" {
+			t.Errorf("migration %04d_%s: missing down.sql", mig.Version, mig.Name)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	m := &Migrator{Migrations: []Migration{
+		{Version: 1, Name: "init", Checksum: "aaa"},
+		{Version: 2, Name: "tags", Checksum: "bbb"},
+	}}
+
+	cases := []struct {
+		name    string
+		applied map[int]appliedMigration
+		wantIs  error  // errors.Is
+		wantErr string // подстрока ошибки
+	}{
+		{"nothing_applied", map[int]appliedMigration{}, nil, ""},
+		{"some_applied", map[int]appliedMigration{1: {checksum: "aaa"}}, nil, ""},
+		{"all_applied", map[int]appliedMigration{1: {checksum: "aaa"}, 2: {checksum: "bbb"}}, nil, ""},
+		{"modified", map[int]appliedMigration{1: {checksum: "aaa"}, 2: {checksum: "zzz"}}, ErrChecksumMismatch, "0002_tags"},
+		{"file_missing", map[int]appliedMigration{1: {checksum: "aaa"}, 3: {checksum: "ccc"}}, nil, "migration 3 is applied but its file is missing"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := m.verify(c.applied)
+			if c.wantIs == nil && c.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if c.wantIs != nil && !errors.Is(err, c.wantIs) {
+				t.Fatalf("err = %v; want errors.Is %v", err, c.wantIs)
+			}
+			if c.wantErr != "" && !strings.Contains(err.Error(), c.wantErr) {
+				t.Fatalf("err = %v; want %q", err, c.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+-- схема, которую раньше создавал AutoMigrate при старте сервера;
+-- IF NOT EXISTS — чтобы базу, созданную AutoMigrate, можно было взять под миграции как есть
+CREATE TABLE IF NOT EXISTS users (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    email      VARCHAR(200) NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS notes (
+    id         BIGSERIAL PRIMARY KEY,
+    title      VARCHAR(200) NOT NULL,
+    content    TEXT,
+    user_id    BIGINT       NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    CONSTRAINT fk_users_notes FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+
+-- связь M:N заметок и тегов (many2many:note_tags)
+CREATE TABLE IF NOT EXISTS note_tags (
+    note_id BIGINT NOT NULL,
+    tag_id  BIGINT NOT NULL,
+    PRIMARY KEY (note_id, tag_id),
+    CONSTRAINT fk_note_tags_note FOREIGN KEY (note_id) REFERENCES notes (id),
+    CONSTRAINT fk_note_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
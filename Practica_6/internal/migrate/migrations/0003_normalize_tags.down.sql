@@ -0,0 +1,2 @@
+-- слитые теги и исходное написание имён не восстанавливаются
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS chk_tags_name;
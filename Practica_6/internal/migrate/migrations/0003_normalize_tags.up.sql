@@ -0,0 +1,21 @@
+-- теги, созданные до нормализации имён, приводим к каноническому виду
+-- (нижний регистр, пробелы схлопнуты и обрезаны, как normalizeTag в internal/http),
+-- а теги, совпадающие после этого, сливаем в тег с наименьшим id
+CREATE TEMP TABLE tag_merge ON COMMIT DROP AS
+SELECT id, norm, min(id) OVER (PARTITION BY norm) AS keep_id
+FROM (SELECT id, lower(btrim(regexp_replace(name, '[[:space:]]+', ' ', 'g'))) AS norm FROM tags) t;
+
+-- связи дублей переносим на оставшийся тег; если заметка уже с ним связана — связь дубля просто удаляется
+INSERT INTO note_tags (note_id, tag_id)
+SELECT nt.note_id, m.keep_id
+FROM note_tags nt JOIN tag_merge m ON m.id = nt.tag_id
+WHERE m.id <> m.keep_id
+ON CONFLICT DO NOTHING;
+
+DELETE FROM note_tags nt USING tag_merge m WHERE nt.tag_id = m.id AND m.id <> m.keep_id;
+DELETE FROM tags t USING tag_merge m WHERE t.id = m.id AND m.id <> m.keep_id;
+UPDATE tags t SET name = m.norm FROM tag_merge m WHERE t.id = m.id AND t.name <> m.norm;
+
+-- дальше "Go" рядом с "go" не появится: уникальный idx_tags_name вместе с этой
+-- проверкой даёт уникальность без учёта регистра
+ALTER TABLE tags ADD CONSTRAINT chk_tags_name CHECK (name = lower(trim(name)));
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	ID        uint   `gorm:"primary_Key;"`
@@ -9,6 +13,7 @@ type User struct {
 	Notes     []Note
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"` // не NULL — пользователь в корзине
 }
 
 type Note struct {
@@ -20,12 +25,18 @@ type Note struct {
 	Tags      []Tag `gorm:"many2many:note_tags;"` // M:N
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"` // не NULL — заметка в корзине; связи с тегами сохраняются
 }
 
 type Tag struct {
 	ID        uint   `gorm:"primaryKey"`
-	Name      string `gorm:"size:50;uniqueIndex;not null"`
+	Name      string `gorm:"size:50;uniqueIndex;not null;check:,name = lower(trim(name))"` // только нормализованные имена, см. 0003_normalize_tags
 	Notes     []Note `gorm:"many2many:note_tags;"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+// All — модели в порядке зависимостей; по ним migrate diff сравнивает схему.
+func All() []any {
+	return []any{&User{}, &Note{}, &Tag{}}
+}
@@ -0,0 +1,77 @@
+package trash
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/prac_6/internal/models"
+	"gorm.io/gorm"
+)
+
+// Purger окончательно удаляет пользователей и заметки, пролежавшие
+// в корзине (deleted_at не NULL) дольше Retention.
+type Purger struct {
+	DB        *gorm.DB
+	Retention time.Duration
+	Interval  time.Duration    // как часто проверять корзину
+	Now       func() time.Time // для тестов; по умолчанию time.Now
+}
+
+func NewPurger(db *gorm.DB, retention time.Duration) *Purger {
+	return &Purger{DB: db, Retention: retention, Interval: time.Hour, Now: time.Now}
+}
+
+// Result — сколько записей удалено за один проход.
+type Result struct {
+	Notes int64
+	Users int64
+}
+
+// Run чистит корзину сразу и затем каждые Interval, пока не отменён ctx.
+func (p *Purger) Run(ctx context.Context) {
+	t := time.NewTicker(p.Interval)
+	defer t.Stop()
+	for {
+		res, err := p.Purge(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Println("trash purge:", err)
+		case res.Notes > 0 || res.Users > 0:
+			log.Printf("trash purge: removed %d notes, %d users", res.Notes, res.Users)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Purge удаляет одной транзакцией всё, что удалено раньше Now()-Retention.
+// Вместе с пользователем удаляются все его заметки (внешний ключ notes.user_id),
+// вместе с заметками — их строки в note_tags; сами теги остаются.
+func (p *Purger) Purge(ctx context.Context) (Result, error) {
+	cutoff := p.Now().Add(-p.Retention)
+	var res Result
+	err := p.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// каждый запрос строится от tx заново: цепочка от одного Unscoped()
+		// разделяла бы общий Statement
+		users := tx.Unscoped().Model(&models.User{}).Select("id").Where("deleted_at < ?", cutoff)
+		notes := tx.Unscoped().Model(&models.Note{}).Select("id").
+			Where("deleted_at < ? OR user_id IN (?)", cutoff, users)
+
+		if err := tx.Exec("DELETE FROM note_tags WHERE note_id IN (?)", notes).Error; err != nil {
+			return err
+		}
+		r := tx.Unscoped().Where("deleted_at < ? OR user_id IN (?)", cutoff, users).Delete(&models.Note{})
+		if r.Error != nil {
+			return r.Error
+		}
+		res.Notes = r.RowsAffected
+		r = tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.User{})
+		res.Users = r.RowsAffected
+		return r.Error
+	})
+	return res, err
+}
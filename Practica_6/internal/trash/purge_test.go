@@ -0,0 +1,105 @@
+package trash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/prac_6/internal/models"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB — SQLite в памяти со схемой из моделей; своя база на каждый тест.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	d, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := d.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := d.AutoMigrate(models.All()...); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	return d
+}
+
+func TestPurge(t *testing.T) {
+	d := openTestDB(t)
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-48 * time.Hour)   // дольше срока хранения
+	recent := now.Add(-1 * time.Hour) // ещё в пределах срока
+
+	trashed := func(at time.Time) gorm.DeletedAt { return gorm.DeletedAt{Time: at, Valid: true} }
+	tag := models.Tag{Name: "go"}
+	users := []models.User{
+		{Name: "live", Email: "live@example.com"},
+		{Name: "old", Email: "old@example.com", DeletedAt: trashed(old)},
+		{Name: "recent", Email: "recent@example.com", DeletedAt: trashed(recent)},
+	}
+	if err := d.Create(&tag).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	live, oldUser, recentUser := users[0].ID, users[1].ID, users[2].ID
+	notes := []models.Note{
+		{Title: "live", UserID: live, Tags: []models.Tag{tag}},
+		{Title: "old note", UserID: live, DeletedAt: trashed(old), Tags: []models.Tag{tag}},
+		{Title: "recent note", UserID: live, DeletedAt: trashed(recent)},
+		// заметка удалённого пользователя удаляется вместе с ним, даже если сама живая
+		{Title: "of old user", UserID: oldUser, Tags: []models.Tag{tag}},
+		{Title: "of recent user", UserID: recentUser, DeletedAt: trashed(recent)},
+	}
+	if err := d.Omit("Tags.*").Create(&notes).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Purger{DB: d, Retention: 24 * time.Hour, Now: func() time.Time { return now }}
+	res, err := p.Purge(context.Background())
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if res.Notes != 2 || res.Users != 1 {
+		t.Fatalf("Purge = %+v; want 2 notes, 1 user", res)
+	}
+
+	var titles []string
+	d.Unscoped().Model(&models.Note{}).Order("id").Pluck("title", &titles)
+	want := []string{"live", "recent note", "of recent user"}
+	if len(titles) != len(want) {
+		t.Fatalf("notes left = %v; want %v", titles, want)
+	}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Fatalf("notes left = %v; want %v", titles, want)
+		}
+	}
+	var userCount, linkCount, tagCount int64
+	d.Unscoped().Model(&models.User{}).Count(&userCount)
+	d.Table("note_tags").Count(&linkCount)
+	d.Model(&models.Tag{}).Count(&tagCount)
+	if userCount != 2 {
+		t.Errorf("users left = %d; want 2", userCount)
+	}
+	if linkCount != 1 {
+		t.Errorf("note_tags left = %d; want 1 (only the live note)", linkCount)
+	}
+	if tagCount != 1 {
+		t.Errorf("tags left = %d; want 1: tags are never purged", tagCount)
+	}
+
+	// повторный проход ничего не находит
+	if res, err := p.Purge(context.Background()); err != nil || res != (Result{}) {
+		t.Fatalf("second Purge = %+v, %v; want nothing", res, err)
+	}
+}